@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// cacheStore is a persistent cache tier that sits behind the in-memory LRU,
+// so cached data survives restarts.
+type cacheStore interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+var versionInfoBucket = []byte("version_info")
+
+// boltCache is a cacheStore backed by a bbolt database on local disk.
+//
+// Entries never expire: tagged version info is effectively immutable.
+type boltCache struct {
+	db *bolt.DB
+}
+
+func openBoltCache(dir string) (*boltCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	db, err := bolt.Open(filepath.Join(dir, "cache.db"), 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache database: %w", err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(versionInfoBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create cache bucket: %w", err)
+	}
+	return &boltCache{db: db}, nil
+}
+
+func (c *boltCache) Close() error {
+	return c.db.Close()
+}
+
+func (c *boltCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var value []byte
+	err := c.db.View(func(tx *bolt.Tx) error {
+		// Values are only valid for the life of the transaction, so copy
+		if v := tx.Bucket(versionInfoBucket).Get([]byte(key)); v != nil {
+			value = append([]byte(nil), v...)
+		}
+		return nil
+	})
+	return value, value != nil, err
+}
+
+func (c *boltCache) Set(ctx context.Context, key string, value []byte) error {
+	return c.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(versionInfoBucket).Put([]byte(key), value)
+	})
+}
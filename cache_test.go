@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+func TestBoltCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	c, err := openBoltCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := c.Get(ctx, "example.com/module@v1.0.0"); err != nil || ok {
+		t.Fatalf("Get before Set: got ok=%t, err=%v", ok, err)
+	}
+	if err := c.Set(ctx, "example.com/module@v1.0.0", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = openBoltCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	got, ok, err := c.Get(ctx, "example.com/module@v1.0.0")
+	if err != nil || !ok || string(got) != "value" {
+		t.Errorf("Get after reopen: got %q, %t, %v, want %q", got, ok, err, "value")
+	}
+}
+
+func TestPersistentCacheSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	var infoFetches atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/example.com/module/@v/v1.0.0.info" {
+			http.NotFound(w, r)
+			return
+		}
+		infoFetches.Add(1)
+		info := VersionInfo{
+			Version: "v1.0.0",
+			Time:    time.Now().Add(-30 * 24 * time.Hour),
+		}
+		json.NewEncoder(w).Encode(info)
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	newProxy := func() (*Proxy, func()) {
+		cache, err := lru.New[string, *VersionInfo](100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store, err := openBoltCache(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Proxy{
+			upstream:        upstream.URL,
+			client:          &http.Client{Timeout: 30 * time.Second},
+			cache:           cache,
+			infoStore:       store,
+			defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+		}, func() { store.Close() }
+	}
+
+	for i := range 2 {
+		proxy, stop := newProxy()
+
+		req := httptest.NewRequest("GET", "/example.com/module/@v/v1.0.0.info", nil)
+		req = req.WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		proxy.ServeHTTP(w, req)
+		stop()
+
+		if w.Code != http.StatusOK {
+			t.Errorf("run %d status: got %d, want %d", i, w.Code, http.StatusOK)
+		}
+	}
+
+	if got := infoFetches.Load(); got != 1 {
+		t.Errorf("upstream info fetches: got %d, want 1", got)
+	}
+}
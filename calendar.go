@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// calendar says which days are business days: weekdays in its location
+// that aren't holidays. A nil calendar is UTC with no holidays.
+type calendar struct {
+	loc      *time.Location
+	holidays map[string]bool // keyed by time.DateOnly
+}
+
+// loadCalendar loads a holiday file, if path isn't empty, with one date per
+// line, like:
+//
+//	# Comments and blank lines are ignored
+//	2025-12-25 Christmas Day
+//	2026-01-01
+func loadCalendar(path, timezone string) (*calendar, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	if path == "" {
+		return &calendar{loc: loc}, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read holidays: %w", err)
+	}
+	return parseCalendar(b, loc)
+}
+
+func parseCalendar(b []byte, loc *time.Location) (*calendar, error) {
+	c := &calendar{loc: loc, holidays: map[string]bool{}}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		d, err := time.Parse(time.DateOnly, fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday on line %d: %w", n, err)
+		}
+		c.holidays[d.Format(time.DateOnly)] = true
+	}
+	return c, s.Err()
+}
+
+func (c *calendar) location() *time.Location {
+	if c == nil {
+		return time.UTC
+	}
+	return c.loc
+}
+
+func (c *calendar) isBusinessDay(t time.Time) bool {
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false
+	}
+	return c == nil || !c.holidays[t.Format(time.DateOnly)]
+}
+
+// subMonths returns the same time of day n months before t, on the same day
+// of the month, or the last day of the month if it's shorter.
+func (c *calendar) subMonths(t time.Time, n int) time.Time {
+	t = t.In(c.location())
+	y, m, d := t.Date()
+	first := time.Date(y, m-time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(d, lastDay)-1)
+}
+
+// subBusinessDays returns the time n business days before t, counting only
+// time that falls on business days. So one business day before Monday at
+// 10:00 is Friday at 10:00.
+func (c *calendar) subBusinessDays(t time.Time, n int) time.Time {
+	t = t.In(c.location())
+	remaining := time.Duration(n) * 24 * time.Hour
+	end := t
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for {
+		if c.isBusinessDay(start) {
+			span := end.Sub(start)
+			if span >= remaining {
+				return end.Add(-remaining)
+			}
+			remaining -= span
+		}
+		end = start
+		start = time.Date(start.Year(), start.Month(), start.Day()-1, 0, 0, 0, 0, start.Location())
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+func TestCooldownCutoff(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no timezone data: %v", err)
+	}
+	cal, err := parseCalendar([]byte(`
+# US holidays
+2025-07-04 Independence Day
+2025-12-25
+`), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := func(s string) time.Time {
+		t.Helper()
+		d, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	for _, tt := range []struct {
+		desc     string
+		cooldown string
+		cal      *calendar
+		now      string
+		want     string
+	}{{
+		desc:     "business day skips the weekend",
+		cooldown: "1bd",
+		now:      "2025-06-09T10:00:00Z", // Monday
+		want:     "2025-06-06T10:00:00Z", // Friday
+	}, {
+		desc:     "business days from the weekend",
+		cooldown: "1bd",
+		now:      "2025-06-08T10:00:00Z", // Sunday
+		want:     "2025-06-06T00:00:00Z",
+	}, {
+		desc:     "a week of business days",
+		cooldown: "5bd",
+		now:      "2025-06-11T12:00:00Z", // Wednesday
+		want:     "2025-06-04T12:00:00Z",
+	}, {
+		desc:     "business days skip holidays",
+		cooldown: "1bd",
+		cal:      cal,
+		now:      "2025-07-07T10:00:00Z", // Monday after Friday, July 4
+		want:     "2025-07-03T10:00:00Z",
+	}, {
+		desc:     "business days in another timezone",
+		cooldown: "1bd",
+		cal:      &calendar{loc: ny},
+		now:      "2025-06-09T02:00:00Z", // Sunday evening in New York
+		want:     "2025-06-06T04:00:00Z", // midnight Friday in New York
+	}, {
+		desc:     "calendar month",
+		cooldown: "1mo",
+		now:      "2025-03-15T10:00:00Z",
+		want:     "2025-02-15T10:00:00Z",
+	}, {
+		desc:     "calendar month clamps to the end of the month",
+		cooldown: "1mo",
+		now:      "2025-03-31T10:00:00Z",
+		want:     "2025-02-28T10:00:00Z",
+	}, {
+		desc:     "calendar months across a year",
+		cooldown: "3mo",
+		now:      "2025-01-31T10:00:00Z",
+		want:     "2024-10-31T10:00:00Z",
+	}, {
+		desc:     "weeks",
+		cooldown: "2w",
+		now:      "2025-06-15T10:00:00Z",
+		want:     "2025-06-01T10:00:00Z",
+	}, {
+		desc:     "combined",
+		cooldown: "1mo1bd12h",
+		now:      "2025-07-14T10:00:00Z", // Monday
+		want:     "2025-06-12T12:00:00Z", // Saturday June 14, then Friday June 13 00:00, then 12h
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			c, err := parseCooldown(tt.cooldown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := c.Cutoff(date(tt.now), tt.cal), date(tt.want); !got.Equal(want) {
+				t.Errorf("Cutoff(%s) = %v, want %v", tt.now, got, want)
+			}
+		})
+	}
+}
+
+func TestParseCalendarErrors(t *testing.T) {
+	if _, err := parseCalendar([]byte("2025-12-25\nChristmas\n"), time.UTC); err == nil {
+		t.Errorf("expected error for invalid date")
+	}
+	if _, err := loadCalendar("", "Not/A_Zone"); err == nil {
+		t.Errorf("expected error for invalid timezone")
+	}
+	if _, err := loadCalendar("/does/not/exist", "UTC"); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+func TestCalendarCooldownProxy(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	// v1.0.0 was published just before the cutoff for 5bd, v1.1.0 just after
+	cutoff := Cooldown{businessDays: 5}.Cutoff(time.Now(), nil)
+	times := map[string]time.Time{
+		"v1.0.0": cutoff.Add(-time.Minute),
+		"v1.1.0": cutoff.Add(time.Minute),
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			w.Write([]byte("v1.0.0\nv1.1.0\n"))
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: times[version]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		defaultCooldown: Cooldown{businessDays: 5},
+		listConcurrency: 4,
+		listTimeout:     30 * time.Second,
+	}
+
+	for _, tt := range []struct {
+		desc string
+		path string
+		want string
+	}{
+		{"default", "/example.com/module/@v/list", "v1.0.0\n"},
+		{"URL prefix", "/5bd/example.com/module/@v/list", "v1.0.0\n"},
+		{"shorter URL prefix", "/1bd/example.com/module/@v/list", "v1.0.0\nv1.1.0\n"},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("status: got %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("body: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cooldown is how long a version must wait before it's served. Besides a
+// fixed duration, it can include business days and calendar months, whose
+// lengths depend on when the cooldown ends.
+type Cooldown struct {
+	fixed        time.Duration
+	businessDays int
+	months       int
+}
+
+// fixedCooldown returns a cooldown of exactly d.
+func fixedCooldown(d time.Duration) Cooldown {
+	return Cooldown{fixed: d}
+}
+
+// parseCooldown parses anything parseDuration does, plus business days
+// (bd), calendar months (mo) and weeks (w), in any combination, e.g. "5bd",
+// "1mo" or "2w3d". It also parses ISO 8601 durations, like "P30D".
+func parseCooldown(s string) (Cooldown, error) {
+	if strings.HasPrefix(s, "P") {
+		return parseISODuration(s)
+	}
+	if d, err := parseDuration(s); err == nil {
+		return fixedCooldown(d), nil
+	}
+
+	isNum := func(r rune) bool { return r >= '0' && r <= '9' || r == '.' }
+
+	var c Cooldown
+	var fixed strings.Builder
+	for rest := s; rest != ""; {
+		i := strings.IndexFunc(rest, func(r rune) bool { return !isNum(r) })
+		if i <= 0 {
+			return Cooldown{}, fmt.Errorf("invalid cooldown: %s", s)
+		}
+		num := rest[:i]
+		rest = rest[i:]
+		j := strings.IndexFunc(rest, isNum)
+		if j < 0 {
+			j = len(rest)
+		}
+		unit := rest[:j]
+		rest = rest[j:]
+
+		switch unit {
+		case "bd", "mo":
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return Cooldown{}, fmt.Errorf("invalid cooldown: %s: %s must be a whole number", s, unit)
+			}
+			if unit == "bd" {
+				c.businessDays += n
+			} else {
+				c.months += n
+			}
+		case "w":
+			f, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return Cooldown{}, fmt.Errorf("invalid cooldown: %s", s)
+			}
+			c.fixed += time.Duration(f * float64(7*24*time.Hour))
+		default:
+			fixed.WriteString(num + unit)
+		}
+	}
+	if fixed.Len() > 0 {
+		d, err := parseDuration(fixed.String())
+		if err != nil {
+			return Cooldown{}, fmt.Errorf("invalid cooldown: %s", s)
+		}
+		c.fixed += d
+	}
+	return c, nil
+}
+
+// Cutoff returns the latest time a version can have been published and be
+// past the cooldown at now. Calendar months are subtracted first, then
+// business days, then the fixed duration.
+func (c Cooldown) Cutoff(now time.Time, cal *calendar) time.Time {
+	t := now
+	if c.months > 0 {
+		t = cal.subMonths(t, c.months)
+	}
+	if c.businessDays > 0 {
+		t = cal.subBusinessDays(t, c.businessDays)
+	}
+	return t.Add(-c.fixed)
+}
+
+// parseISODuration parses an ISO 8601 duration, like "P30D" or
+// "P1Y2M10DT2H30M". Years and months are calendar months, while weeks, days
+// and smaller units are fixed, with 24-hour days. Only weeks, days and time
+// units can be fractional.
+func parseISODuration(s string) (Cooldown, error) {
+	invalid := fmt.Errorf("invalid ISO 8601 duration: %s", s)
+
+	rest, _ := strings.CutPrefix(s, "P")
+	datePart, timePart, hasTime := strings.Cut(rest, "T")
+	if rest == "" || hasTime && timePart == "" {
+		return Cooldown{}, invalid
+	}
+
+	var c Cooldown
+	for _, part := range []struct {
+		s     string
+		units string
+	}{{datePart, "YMWD"}, {timePart, "HMS"}} {
+		next := 0 // units must appear in order, at most once
+		for rest := part.s; rest != ""; {
+			i := strings.IndexFunc(rest, func(r rune) bool { return !(r >= '0' && r <= '9' || r == '.' || r == ',') })
+			if i <= 0 {
+				return Cooldown{}, invalid
+			}
+			num := strings.Replace(rest[:i], ",", ".", 1)
+			u := strings.IndexByte(part.units[next:], rest[i])
+			if u < 0 {
+				return Cooldown{}, invalid
+			}
+			unit := part.units[next+u]
+			next += u + 1
+			rest = rest[i+1:]
+
+			var err error
+			var d time.Duration
+			switch {
+			case part.units == "YMWD" && (unit == 'Y' || unit == 'M'):
+				var n int
+				if n, err = strconv.Atoi(num); err == nil {
+					if unit == 'Y' {
+						n *= 12
+					}
+					c.months += n
+				}
+			case unit == 'W':
+				d, err = parseDuration(num + "d")
+				d *= 7
+			case unit == 'D':
+				d, err = parseDuration(num + "d")
+			default:
+				d, err = time.ParseDuration(num + strings.ToLower(string(unit)))
+			}
+			if err != nil {
+				return Cooldown{}, invalid
+			}
+			c.fixed += d
+		}
+	}
+	return c, nil
+}
+
+// String returns the cooldown in canonical form: an ISO 8601 duration like
+// "P1Y2M3DT4H", or, if it has business days, which ISO 8601 can't express,
+// the form parseCooldown accepts, like "1mo5bd". Either way, parseCooldown
+// parses it back to the same cooldown.
+func (c Cooldown) String() string {
+	var b strings.Builder
+	if c.businessDays != 0 || c.fixed < 0 {
+		if c.months != 0 {
+			fmt.Fprintf(&b, "%dmo", c.months)
+		}
+		if c.businessDays != 0 {
+			fmt.Fprintf(&b, "%dbd", c.businessDays)
+		}
+		if c.fixed != 0 || b.Len() == 0 {
+			b.WriteString(c.fixed.String())
+		}
+		return b.String()
+	}
+
+	b.WriteString("P")
+	if y := c.months / 12; y > 0 {
+		fmt.Fprintf(&b, "%dY", y)
+	}
+	if m := c.months % 12; m > 0 {
+		fmt.Fprintf(&b, "%dM", m)
+	}
+	day := 24 * time.Hour
+	if d := c.fixed / day; d > 0 {
+		fmt.Fprintf(&b, "%dD", d)
+	}
+	if rem := c.fixed % day; rem > 0 {
+		b.WriteString("T")
+		if h := rem / time.Hour; h > 0 {
+			fmt.Fprintf(&b, "%dH", h)
+		}
+		if m := rem % time.Hour / time.Minute; m > 0 {
+			fmt.Fprintf(&b, "%dM", m)
+		}
+		if s := rem % time.Minute; s > 0 {
+			fmt.Fprintf(&b, "%sS", strconv.FormatFloat(s.Seconds(), 'f', -1, 64))
+		}
+	}
+	if b.Len() == 1 {
+		return "PT0S"
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseCooldown(t *testing.T) {
+	day := 24 * time.Hour
+	for _, tt := range []struct {
+		input   string
+		want    Cooldown
+		wantErr bool
+	}{
+		{input: "7d", want: fixedCooldown(7 * day)},
+		{input: "1d12h", want: fixedCooldown(36 * time.Hour)},
+		{input: "2M", want: fixedCooldown(60 * day)},
+		{input: "2w", want: fixedCooldown(14 * day)},
+		{input: "1w3d", want: fixedCooldown(10 * day)},
+		{input: "0.5w", want: fixedCooldown(84 * time.Hour)},
+		{input: "5bd", want: Cooldown{businessDays: 5}},
+		{input: "1mo", want: Cooldown{months: 1}},
+		{input: "1mo2bd12h", want: Cooldown{months: 1, businessDays: 2, fixed: 12 * time.Hour}},
+		{input: "1y1mo", want: Cooldown{months: 1, fixed: 365 * day}},
+		{input: "P30D", want: fixedCooldown(30 * day)},
+		{input: "P1Y2M", want: Cooldown{months: 14}},
+		{input: "P2W", want: fixedCooldown(14 * day)},
+		{input: "P1DT12H", want: fixedCooldown(36 * time.Hour)},
+		{input: "PT1M", want: fixedCooldown(time.Minute)},
+		{input: "P1M", want: Cooldown{months: 1}},
+		{input: "P1Y2M10DT2H30M15.5S", want: Cooldown{months: 14, fixed: 10*day + 2*time.Hour + 30*time.Minute + 15500*time.Millisecond}},
+		{input: "P0,5D", want: fixedCooldown(12 * time.Hour)},
+		{input: "PT0S", want: Cooldown{}},
+		{input: "P", wantErr: true},
+		{input: "PT", wantErr: true},
+		{input: "P1H", wantErr: true},
+		{input: "PT1D", wantErr: true},
+		{input: "P1D1Y", wantErr: true},
+		{input: "P1D1D", wantErr: true},
+		{input: "P1.5M", wantErr: true},
+		{input: "P1", wantErr: true},
+		{input: "PD", wantErr: true},
+		{input: "1.5bd", wantErr: true},
+		{input: "bd", wantErr: true},
+		{input: "5xd", wantErr: true},
+		{input: "1mo5", wantErr: true},
+	} {
+		got, err := parseCooldown(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCooldown(%q): expected error, got %+v", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseCooldown(%q): %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCooldown(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCooldownString(t *testing.T) {
+	day := 24 * time.Hour
+	for _, tt := range []struct {
+		cooldown Cooldown
+		want     string
+	}{
+		{Cooldown{}, "PT0S"},
+		{fixedCooldown(7 * day), "P7D"},
+		{fixedCooldown(36 * time.Hour), "P1DT12H"},
+		{fixedCooldown(90 * time.Minute), "PT1H30M"},
+		{fixedCooldown(1500 * time.Millisecond), "PT1.5S"},
+		{fixedCooldown(time.Nanosecond), "PT0.000000001S"},
+		{Cooldown{months: 14}, "P1Y2M"},
+		{Cooldown{months: 1, fixed: 3*day + time.Hour}, "P1M3DT1H"},
+		{Cooldown{businessDays: 5}, "5bd"},
+		{Cooldown{months: 1, businessDays: 2, fixed: 12 * time.Hour}, "1mo2bd12h0m0s"},
+		{fixedCooldown(-time.Hour), "-1h0m0s"},
+	} {
+		got := tt.cooldown.String()
+		if got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.cooldown, got, tt.want)
+		}
+
+		// It round-trips
+		parsed, err := parseCooldown(got)
+		if err != nil {
+			t.Errorf("parseCooldown(%q): %v", got, err)
+			continue
+		}
+		if parsed != tt.cooldown {
+			t.Errorf("parseCooldown(%q) = %+v, want %+v", got, parsed, tt.cooldown)
+		}
+	}
+}
+
+func TestCooldownHeader(t *testing.T) {
+	// The upstream isn't reachable, but the header is set before anything
+	// is fetched.
+	proxy := &Proxy{
+		upstream:        "http://127.0.0.1:0",
+		client:          &http.Client{Timeout: time.Second},
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+	}
+	policy, err := parsePolicy([]byte("cooldowns:\n  golang.org/x/*: P1M\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		desc   string
+		policy *Policy
+		path   string
+		want   string
+	}{
+		{"default", nil, "/example.com/module/@v/list", "P7D"},
+		{"URL prefix", nil, "/720h/example.com/module/@v/list", "P30D"},
+		{"ISO 8601 URL prefix", nil, "/P1Y/example.com/module/@latest", "P1Y"},
+		{"absolute cutoff", nil, "/at/2025-06-01/example.com/module/@v/list", "2025-06-01T00:00:00Z"},
+		{"policy", policy, "/1d/golang.org/x/net/@v/list", "P1M"},
+		{"URL prefix stricter than policy", policy, "/P2M/golang.org/x/net/@v/list", "P2M"},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			proxy.policy.Store(tt.policy)
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if got := w.Header().Get(cooldownHeader); got != tt.want {
+				t.Errorf("%s: got %q, want %q", cooldownHeader, got, tt.want)
+			}
+		})
+	}
+}
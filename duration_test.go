@@ -66,3 +66,33 @@ func TestParseDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCutoff(t *testing.T) {
+	for _, tt := range []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "2025-06-01T00:00:00Z", want: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{input: "2025-06-01T02:30:00+02:00", want: time.Date(2025, 6, 1, 0, 30, 0, 0, time.UTC)},
+		{input: "2025-06-01", want: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{input: "2025-06-01T00:00:00", wantErr: true},
+		{input: "06/01/2025", wantErr: true},
+		{input: "7d", wantErr: true},
+	} {
+		got, err := parseCutoff(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCutoff(%q): expected error, got %v", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseCutoff(%q): %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseCutoff(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"golang.org/x/mod/module"
+)
+
+// versionCheck holds what's needed to decide which versions of a module may
+// be served in response to a single request.
+type versionCheck struct {
+	modulePath string
+	now        time.Time
+	cutoff     time.Time
+	applied    string // canonical form of the cooldown or absolute cutoff
+	policy     *Policy
+	request    *http.Request
+
+	// prereleaseCutoff applies to prereleases instead of cutoff, unless
+	// they're excluded entirely.
+	prereleaseCutoff   time.Time
+	excludePrereleases bool
+
+	// Pseudo-versions may be forbidden, held to pseudoCutoff as well as
+	// the usual cutoff (if it's set), or only allowed if their base version
+	// is eligible.
+	forbidPseudo bool
+	pseudoCutoff time.Time
+	requireBase  bool
+
+	// tiers, if set, holds the cutoff for each changeTier, which applies
+	// instead of cutoff to versions in that tier.
+	tiers []tierCutoff
+
+	// header holds the response headers, so exemptions can be reported.
+	header http.Header
+}
+
+// cooldownHeader is set on responses to the canonical form of the cooldown
+// applied, like "P7D", or the absolute cutoff, like "2025-06-01T00:00:00Z".
+const cooldownHeader = "X-Cooldown"
+
+func newVersionCheck(w http.ResponseWriter, r *http.Request, now, cutoff time.Time, applied string, policy *Policy, modulePath string) *versionCheck {
+	w.Header().Set(cooldownHeader, applied)
+	return &versionCheck{
+		modulePath: modulePath,
+		now:        now,
+		cutoff:     cutoff,
+		applied:    applied,
+		policy:     policy,
+		request:    r,
+		header:     w.Header(),
+	}
+}
+
+// eligible reports whether version may be served. An override for the
+// version decides first; then known-vulnerable versions are rejected, if
+// blocking is enabled; then versions of modules that are too new are
+// rejected, if quarantine is enabled; then prereleases and pseudo-versions
+// are checked against their own policies; then, if the policy has a rule,
+// the rule decides; otherwise the version must be past its cooldown.
+// Versions that fix a known vulnerability are exempt from the rule or
+// cooldown.
+//
+// With tiered cooldowns, the cooldown depends on how version changes from
+// previous, the previous eligible version (see previousEligible).
+func (p *Proxy) eligible(ctx context.Context, vc *versionCheck, previous, version string, info *VersionInfo) bool {
+	log := clog.FromContext(ctx)
+	t := p.versionTime(ctx, vc.modulePath, version, info)
+
+	if o, ok := p.overrides.Lookup(vc.modulePath, version, vc.now); ok {
+		log.InfoContext(ctx, "version overridden", "version", version, "action", o.Action, "reason", o.Reason)
+		return o.Action == overrideAllow
+	}
+
+	if p.blockVulnerable {
+		if ids := p.vulns.Affecting(vc.modulePath, version, p.blockSeverity); len(ids) > 0 {
+			log.InfoContext(ctx, "version blocked as vulnerable", "version", version, "advisories", ids)
+			return false
+		}
+	}
+
+	if p.quarantined(ctx, vc, version, t) {
+		return false
+	}
+
+	if vc.excludePrereleases && isPrerelease(version) {
+		log.InfoContext(ctx, "prereleases excluded", "version", version)
+		return false
+	}
+
+	if module.IsPseudoVersion(version) && !p.pseudoVersionAllowed(ctx, vc, version, t) {
+		return false
+	}
+
+	if p.cooledDown(ctx, vc, previous, version, t) {
+		return true
+	}
+
+	if ids := p.vulns.Fixes(vc.modulePath, version); len(ids) > 0 {
+		log.InfoContext(ctx, "version exempt from cooldown as a security fix", "version", version, "advisories", ids)
+		vc.header.Add(securityFixHeader, version+" "+strings.Join(ids, ","))
+		return true
+	}
+	return false
+}
+
+// securityFixHeader is added to responses for each version served despite
+// its cooldown because it fixes a vulnerability. Its value is the version
+// and a comma-separated list of advisory IDs, e.g. "v1.2.3 GO-2025-0001".
+const securityFixHeader = "X-Cooldown-Security-Fix"
+
+// cooledDown reports whether version, first seen at t, passes the policy's
+// rule, or its cooldown if there's no rule.
+func (p *Proxy) cooledDown(ctx context.Context, vc *versionCheck, previous, version string, t time.Time) bool {
+	log := clog.FromContext(ctx)
+
+	tc := tierCutoff{cutoff: vc.cutoff, prereleaseCutoff: vc.prereleaseCutoff, applied: vc.applied}
+	if vc.tiers != nil {
+		tier := classifyChange(previous, version)
+		log = log.With("tier", tier, "previous", previous)
+		tc = vc.tiers[tier]
+	}
+	cutoff := tc.cutoff
+	if isPrerelease(version) {
+		cutoff = tc.prereleaseCutoff
+	}
+
+	if vc.policy != nil && vc.policy.rule != nil {
+		allowed, err := vc.policy.rule.Eval(ruleInput{
+			module:   vc.modulePath,
+			version:  version,
+			time:     t,
+			now:      vc.now,
+			cooldown: vc.now.Sub(cutoff),
+			request:  requestMetadata(vc.request),
+		})
+		if err != nil {
+			// Fail closed
+			log.ErrorContext(ctx, "failed to evaluate rule", "version", version, "error", err)
+			return false
+		}
+		if !allowed {
+			log.InfoContext(ctx, "version rejected by rule", "version", version, "time", t)
+		}
+		return allowed
+	}
+
+	if t.After(cutoff) {
+		log.InfoContext(ctx, "version too new", "version", version, "time", t, "cooldown", tc.applied, "cutoff", cutoff)
+		return false
+	}
+	return true
+}
+
+// requestMetadata returns the request fields exposed to rules.
+func requestMetadata(r *http.Request) map[string]string {
+	if r == nil {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"path":       r.URL.Path,
+		"host":       r.Host,
+		"userAgent":  r.UserAgent(),
+		"remoteAddr": r.RemoteAddr,
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	firstSeenBucket = []byte("first_seen")
+	metaBucket      = []byte("meta")
+
+	indexCursorKey = []byte("index_cursor")
+)
+
+// firstSeenStore persists when the proxy first observed each module@version.
+//
+// Upstream .info times are commit times, which the module author controls,
+// so a backdated tag would otherwise clear the cooldown immediately.
+type firstSeenStore struct {
+	db *bolt.DB
+}
+
+func openFirstSeenStore(path string) (*firstSeenStore, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open first-seen database: %w", err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(firstSeenBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metaBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create first-seen bucket: %w", err)
+	}
+	return &firstSeenStore{db: db}, nil
+}
+
+func (s *firstSeenStore) Close() error {
+	return s.db.Close()
+}
+
+// Get returns when modulePath@version was first seen, if it has been.
+func (s *firstSeenStore) Get(modulePath, version string) (time.Time, bool, error) {
+	var t time.Time
+	var ok bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(firstSeenBucket).Get([]byte(modulePath + "@" + version))
+		if v == nil {
+			return nil
+		}
+		ok = true
+		return t.UnmarshalText(v)
+	})
+	return t, ok, err
+}
+
+// Observe returns when modulePath@version was first seen, recording now as
+// the first sighting if it hasn't been seen before.
+func (s *firstSeenStore) Observe(modulePath, version string, now time.Time) (time.Time, error) {
+	if t, ok, err := s.Get(modulePath, version); err != nil || ok {
+		return t, err
+	}
+	return s.Record(modulePath, version, now)
+}
+
+// Record records that modulePath@version was seen at t, unless it was
+// already seen earlier, and returns the earliest sighting.
+func (s *firstSeenStore) Record(modulePath, version string, t time.Time) (time.Time, error) {
+	var earliest time.Time
+	err := s.db.Batch(func(tx *bolt.Tx) error {
+		var err error
+		earliest, err = recordFirstSeen(tx, modulePath, version, t)
+		return err
+	})
+	return earliest, err
+}
+
+// RecordAll records a batch of index entries in a single transaction.
+func (s *firstSeenStore) RecordAll(entries []indexEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, e := range entries {
+			if _, err := recordFirstSeen(tx, e.Path, e.Version, e.Timestamp); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func recordFirstSeen(tx *bolt.Tx, modulePath, version string, t time.Time) (time.Time, error) {
+	b := tx.Bucket(firstSeenBucket)
+	key := []byte(modulePath + "@" + version)
+	t = t.UTC()
+	if v := b.Get(key); v != nil {
+		var existing time.Time
+		if err := existing.UnmarshalText(v); err != nil {
+			return time.Time{}, err
+		}
+		if !existing.After(t) {
+			return existing, nil
+		}
+	}
+	v, err := t.MarshalText()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t, b.Put(key, v)
+}
+
+// IndexCursor returns the timestamp the index feed has been consumed up to,
+// or the zero time if it hasn't been consumed at all.
+func (s *firstSeenStore) IndexCursor() (time.Time, error) {
+	var t time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(metaBucket).Get(indexCursorKey)
+		if v == nil {
+			return nil
+		}
+		return t.UnmarshalText(v)
+	})
+	return t, err
+}
+
+// SetIndexCursor records the timestamp the index feed has been consumed up to.
+func (s *firstSeenStore) SetIndexCursor(t time.Time) error {
+	v, err := t.UTC().MarshalText()
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(indexCursorKey, v)
+	})
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+func TestFirstSeenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "first-seen.db")
+	s, err := openFirstSeenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+
+	if _, ok, err := s.Get("example.com/module", "v1.0.0"); err != nil || ok {
+		t.Fatalf("Get before Observe: got ok=%t, err=%v", ok, err)
+	}
+
+	got, err := s.Observe("example.com/module", "v1.0.0", t1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(t1) {
+		t.Errorf("first Observe: got %v, want %v", got, t1)
+	}
+
+	// Later sightings don't move the first-seen time
+	if got, err := s.Observe("example.com/module", "v1.0.0", t1.Add(time.Hour)); err != nil || !got.Equal(t1) {
+		t.Errorf("second Observe: got %v, %v, want %v", got, err, t1)
+	}
+	if got, err := s.Record("example.com/module", "v1.0.0", t1.Add(time.Hour)); err != nil || !got.Equal(t1) {
+		t.Errorf("Record later: got %v, %v, want %v", got, err, t1)
+	}
+
+	// Earlier sightings do
+	if got, err := s.Record("example.com/module", "v1.0.0", t0); err != nil || !got.Equal(t0) {
+		t.Errorf("Record earlier: got %v, %v, want %v", got, err, t0)
+	}
+
+	// And they survive a restart
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = openFirstSeenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got, ok, err := s.Get("example.com/module", "v1.0.0"); err != nil || !ok || !got.Equal(t0) {
+		t.Errorf("Get after reopen: got %v, %t, %v, want %v", got, ok, err, t0)
+	}
+}
+
+func TestFirstSeenCooldown(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/module/@v/v1.0.0.info":
+			info := VersionInfo{
+				Version: "v1.0.0",
+				Time:    time.Now().Add(-30 * 24 * time.Hour),
+			}
+			json.NewEncoder(w).Encode(info)
+
+		case "/example.com/module/@v/v1.0.1.info":
+			// Backdated commit time, but only just published
+			info := VersionInfo{
+				Version: "v1.0.1",
+				Time:    time.Now().Add(-365 * 24 * time.Hour),
+			}
+			json.NewEncoder(w).Encode(info)
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	firstSeen, err := openFirstSeenStore(filepath.Join(t.TempDir(), "first-seen.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer firstSeen.Close()
+
+	// The proxy saw v1.0.0 long ago
+	if _, err := firstSeen.Record("example.com/module", "v1.0.0", time.Now().Add(-20*24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+		firstSeen:       firstSeen,
+	}
+
+	for _, tt := range []struct {
+		desc       string
+		path       string
+		wantStatus int
+	}{
+		{
+			desc:       "version seen long ago is allowed",
+			path:       "/example.com/module/@v/v1.0.0.info",
+			wantStatus: http.StatusOK,
+		},
+		{
+			desc:       "backdated version seen just now is filtered",
+			path:       "/example.com/module/@v/v1.0.1.info",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			desc:       "backdated version can't be downloaded",
+			path:       "/example.com/module/@v/v1.0.1.zip",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			desc:       "backdated version is allowed with a short cooldown",
+			path:       "/0s/example.com/module/@v/v1.0.1.info",
+			wantStatus: http.StatusOK,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	if _, ok, err := firstSeen.Get("example.com/module", "v1.0.1"); err != nil || !ok {
+		t.Errorf("v1.0.1 should have been recorded as seen: ok=%t, err=%v", ok, err)
+	}
+}
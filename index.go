@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"golang.org/x/mod/module"
+)
+
+// indexEntry is a line in the module index feed, as served by
+// https://index.golang.org/index.
+type indexEntry struct {
+	Path      string    `json:"Path"`
+	Version   string    `json:"Version"`
+	Timestamp time.Time `json:"Timestamp"`
+}
+
+// indexPoller consumes the module index feed in the background, recording
+// each version's publish time as its first-seen time. Those are the times
+// the upstream proxy first saw each version, which unlike commit times can't
+// be backdated by the module author.
+type indexPoller struct {
+	url      string
+	client   *http.Client
+	store    *firstSeenStore
+	interval time.Duration
+	limit    int
+
+	// warm lists module path prefixes whose new versions should have their
+	// info fetched into the proxy's cache as soon as they're indexed.
+	warm  []string
+	proxy *Proxy
+}
+
+// Run polls the index until ctx is done. It catches up as fast as the feed
+// allows, then polls every interval.
+func (ip *indexPoller) Run(ctx context.Context) {
+	log := clog.FromContext(ctx)
+
+	for {
+		n, err := ip.poll(ctx)
+		if err != nil {
+			log.ErrorContext(ctx, "failed to poll index", "error", err)
+		}
+
+		// A full page means there's probably more to catch up on
+		if err == nil && n >= ip.limit {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ip.interval):
+		}
+	}
+}
+
+// poll fetches and records a single page of the index, returning the number
+// of entries it contained.
+func (ip *indexPoller) poll(ctx context.Context) (int, error) {
+	log := clog.FromContext(ctx)
+
+	since, err := ip.store.IndexCursor()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read index cursor: %w", err)
+	}
+
+	q := url.Values{"limit": {strconv.Itoa(ip.limit)}}
+	if !since.IsZero() {
+		q.Set("since", since.Format(time.RFC3339Nano))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ip.url+"?"+q.Encode(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := ip.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("index returned status %d", resp.StatusCode)
+	}
+
+	var entries []indexEntry
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e indexEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return 0, fmt.Errorf("failed to parse index entry %q: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	if err := ip.store.RecordAll(entries); err != nil {
+		return 0, fmt.Errorf("failed to record index entries: %w", err)
+	}
+
+	// since is inclusive, so the next page starts at the last timestamp. If a
+	// whole page shares that timestamp we'd never make progress, so skip past it.
+	next := entries[len(entries)-1].Timestamp
+	if len(entries) >= ip.limit && !next.After(since) {
+		log.WarnContext(ctx, "index page didn't advance, skipping ahead", "since", since)
+		next = since.Add(time.Nanosecond)
+	}
+	if err := ip.store.SetIndexCursor(next); err != nil {
+		return 0, fmt.Errorf("failed to write index cursor: %w", err)
+	}
+	log.InfoContext(ctx, "recorded index entries", "count", len(entries), "cursor", next)
+
+	ip.prewarm(ctx, entries)
+
+	return len(entries), nil
+}
+
+// prewarm fetches info for entries belonging to modules in ip.warm.
+func (ip *indexPoller) prewarm(ctx context.Context, entries []indexEntry) {
+	if ip.proxy == nil || len(ip.warm) == 0 {
+		return
+	}
+	log := clog.FromContext(ctx)
+
+	for _, e := range entries {
+		if !hasModulePrefix(e.Path, ip.warm) {
+			continue
+		}
+		// Requests use escaped paths and versions, and so does the cache
+		modulePath, err := module.EscapePath(e.Path)
+		if err != nil {
+			log.WarnContext(ctx, "invalid module path in index", "module", e.Path, "error", err)
+			continue
+		}
+		version, err := module.EscapeVersion(e.Version)
+		if err != nil {
+			log.WarnContext(ctx, "invalid version in index", "module", e.Path, "version", e.Version, "error", err)
+			continue
+		}
+		if _, err := ip.proxy.fetchVersionInfo(ctx, modulePath, version); err != nil {
+			log.WarnContext(ctx, "failed to pre-warm version info", "module", e.Path, "version", e.Version, "error", err)
+			continue
+		}
+		log.DebugContext(ctx, "pre-warmed version info", "module", e.Path, "version", e.Version)
+	}
+}
+
+// hasModulePrefix reports whether modulePath is one of prefixes, or is
+// nested under one of them.
+func hasModulePrefix(modulePath string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if modulePath == prefix || strings.HasPrefix(modulePath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// fakeIndex serves entries in the index.golang.org format, honoring the
+// since and limit parameters.
+func fakeIndex(t *testing.T, entries []indexEntry) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var since time.Time
+		if s := r.URL.Query().Get("since"); s != "" {
+			var err error
+			if since, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				t.Errorf("bad since %q: %v", s, err)
+			}
+		}
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil {
+			t.Errorf("bad limit: %v", err)
+		}
+
+		enc := json.NewEncoder(w)
+		n := 0
+		for _, e := range entries {
+			if e.Timestamp.Before(since) || n >= limit {
+				continue
+			}
+			enc.Encode(e)
+			n++
+		}
+	}))
+}
+
+func TestIndexPoller(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	base := time.Now().Add(-10 * 24 * time.Hour).UTC().Truncate(time.Second)
+	entries := []indexEntry{
+		{Path: "example.com/module", Version: "v1.0.0", Timestamp: base},
+		{Path: "example.com/other", Version: "v0.1.0", Timestamp: base.Add(time.Hour)},
+		{Path: "example.com/module", Version: "v1.1.0", Timestamp: base.Add(2 * time.Hour)},
+		{Path: "example.com/module/sub", Version: "v1.0.0", Timestamp: base.Add(3 * time.Hour)},
+		{Path: "example.com/BurntSushi/toml", Version: "v1.0.0-RC1", Timestamp: base.Add(4 * time.Hour)},
+		{Path: "example.com/module", Version: "v1.2.0", Timestamp: time.Now().Add(-time.Hour).UTC()},
+	}
+	index := fakeIndex(t, entries)
+	defer index.Close()
+
+	var warmed []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		warmed = append(warmed, r.URL.Path)
+		json.NewEncoder(w).Encode(VersionInfo{
+			Version: "v1.0.0",
+			// Backdated commit time
+			Time: time.Now().Add(-365 * 24 * time.Hour),
+		})
+	}))
+	defer upstream.Close()
+
+	store, err := openFirstSeenStore(filepath.Join(t.TempDir(), "first-seen.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+		firstSeen:       store,
+	}
+
+	poller := &indexPoller{
+		url:    index.URL,
+		client: &http.Client{Timeout: 30 * time.Second},
+		store:  store,
+		limit:  2,
+		warm:   []string{"example.com/module", "example.com/BurntSushi"},
+		proxy:  proxy,
+	}
+
+	// Poll until caught up: pages overlap by one entry since "since" is inclusive
+	for i := 0; ; i++ {
+		if i > 10 {
+			t.Fatal("poller didn't catch up")
+		}
+		n, err := poller.poll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n < poller.limit {
+			break
+		}
+	}
+
+	for _, e := range entries {
+		got, ok, err := store.Get(e.Path, e.Version)
+		if err != nil || !ok || !got.Equal(e.Timestamp) {
+			t.Errorf("%s@%s: got %v, %t, %v, want %v", e.Path, e.Version, got, ok, err, e.Timestamp)
+		}
+	}
+
+	cursor, err := store.IndexCursor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := entries[len(entries)-1].Timestamp; !cursor.Equal(want) {
+		t.Errorf("cursor: got %v, want %v", cursor, want)
+	}
+
+	// Only example.com/module and its nested modules are pre-warmed
+	for _, path := range warmed {
+		if path == "/example.com/other/@v/v0.1.0.info" {
+			t.Errorf("unexpected pre-warm of %s", path)
+		}
+	}
+	for _, key := range []string{"example.com/module/sub@v1.0.0", "example.com/!burnt!sushi/toml@v1.0.0-!r!c1"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("%s should have been pre-warmed", key)
+		}
+	}
+
+	// The cooldown is measured from the index timestamp, not the backdated commit time
+	for _, tt := range []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/example.com/module/@v/v1.1.0.info", http.StatusOK},
+		{"/example.com/module/@v/v1.2.0.info", http.StatusNotFound},
+		// Requests use escaped paths and versions, which must find the
+		// index entries for the unescaped ones
+		{"/example.com/!burnt!sushi/toml/@v/v1.0.0-!r!c1.info", http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req = req.WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		proxy.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s status: got %d, want %d", tt.path, w.Code, tt.wantStatus)
+		}
+	}
+}
+
+func TestHasModulePrefix(t *testing.T) {
+	prefixes := []string{"golang.org/x", "k8s.io/client-go/"}
+	for _, tt := range []struct {
+		path string
+		want bool
+	}{
+		{"golang.org/x", true},
+		{"golang.org/x/net", true},
+		{"golang.org/xyz", false},
+		{"k8s.io/client-go", true},
+		{"k8s.io/api", false},
+	} {
+		if got := hasModulePrefix(tt.path, prefixes); got != tt.want {
+			t.Errorf("hasModulePrefix(%q) = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+}
@@ -3,25 +3,115 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"maps"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/chainguard-dev/clog"
 	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/hashicorp/golang-lru/v2/expirable"
 	"github.com/sethvargo/go-envconfig"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 )
 
 var cfg = envconfig.MustProcess(context.Background(), &(struct {
-	Port            int    `env:"PORT,default=8080"`
-	UpstreamProxy   string `env:"UPSTREAM_PROXY,default=https://proxy.golang.org"`
-	CacheSize       int    `env:"CACHE_SIZE,default=10000"`
-	DefaultCooldown string `env:"DEFAULT_COOLDOWN,default=7d"`
+	Port             int    `env:"PORT,default=8080"`
+	UpstreamProxy    string `env:"UPSTREAM_PROXY,default=https://proxy.golang.org"`
+	CacheSize        int    `env:"CACHE_SIZE,default=10000"`
+	CacheDir         string `env:"CACHE_DIR"`
+	ListCacheSize    int    `env:"LIST_CACHE_SIZE,default=1000"`
+	ListCacheTTL     string `env:"LIST_CACHE_TTL,default=5m"`
+	ListConcurrency  int    `env:"LIST_CONCURRENCY,default=16"`
+	ListTimeout      string `env:"LIST_TIMEOUT,default=30s"`
+	TileCacheSize    int    `env:"TILE_CACHE_SIZE,default=10000"`
+	RetractCacheSize int    `env:"RETRACT_CACHE_SIZE,default=1000"`
+	GoModCacheSize   int    `env:"GO_MOD_CACHE_SIZE,default=1000"`
+	DefaultCooldown  string `env:"DEFAULT_COOLDOWN,default=7d"`
+	PolicyFile       string `env:"POLICY_FILE"`
+
+	// Business days (bd) and calendar months (mo) in cooldowns are counted
+	// in CALENDAR_TIMEZONE, skipping weekends and the dates in HOLIDAYS_FILE.
+	HolidaysFile     string `env:"HOLIDAYS_FILE"`
+	CalendarTimezone string `env:"CALENDAR_TIMEZONE,default=UTC"`
+
+	// If set, prereleases are held to this cooldown instead, if it's
+	// longer, or never served if it's "never".
+	PrereleaseCooldown string `env:"PRERELEASE_COOLDOWN"`
+
+	// If set, how pseudo-versions are treated: "never" to never serve them,
+	// "base" to only serve them if their base version is eligible, and/or a
+	// cooldown they're held to as well, comma-separated.
+	PseudoVersions string `env:"PSEUDO_VERSIONS"`
+
+	// If set, versions are held to these cooldowns instead of
+	// DEFAULT_COOLDOWN, depending on whether they're a patch, minor or
+	// major update from the previous eligible version.
+	PatchCooldown string `env:"PATCH_COOLDOWN"`
+	MinorCooldown string `env:"MINOR_COOLDOWN"`
+	MajorCooldown string `env:"MAJOR_COOLDOWN"`
+
+	// If set, no version of a module is served until its earliest version
+	// is at least this old, except for modules under the path prefixes in
+	// NEW_MODULE_QUARANTINE_EXEMPT.
+	NewModuleQuarantine       string   `env:"NEW_MODULE_QUARANTINE"`
+	NewModuleQuarantineExempt []string `env:"NEW_MODULE_QUARANTINE_EXEMPT"`
+	ModuleAgeCacheSize        int      `env:"MODULE_AGE_CACHE_SIZE,default=10000"`
+
+	// If set, a YAML list of module@version entries that are always allowed
+	// or denied, regardless of cooldowns.
+	OverridesFile string `env:"OVERRIDES_FILE"`
+
+	// If set, an OSV vulnerability database directory or zip. Versions that
+	// fix a vulnerability in it are exempt from cooldowns.
+	VulnDB string `env:"VULN_DB"`
+
+	// If set, versions affected by an advisory in VULN_DB are never served,
+	// optionally only for advisories of at least VULN_BLOCK_SEVERITY.
+	VulnBlock         bool   `env:"VULN_BLOCK,default=false"`
+	VulnBlockSeverity string `env:"VULN_BLOCK_SEVERITY"`
+
+	// If set, pseudo-version timestamps are checked against upstream rather
+	// than trusted as-is.
+	VerifyPseudoVersions bool `env:"VERIFY_PSEUDO_VERSIONS,default=false"`
+
+	// If set, versions' cooldowns are measured from when the proxy first
+	// observed them, if that's later than their commit time.
+	FirstSeenDB string `env:"FIRST_SEEN_DB"`
+
+	// If set, the module index feed at INDEX_URL is consumed in the
+	// background, recording each version's publish time as its first-seen
+	// time. Requires FIRST_SEEN_DB.
+	IndexURL          string   `env:"INDEX_URL"`
+	IndexPollInterval string   `env:"INDEX_POLL_INTERVAL,default=1m"`
+	IndexPageSize     int      `env:"INDEX_PAGE_SIZE,default=2000"`
+	IndexWarmModules  []string `env:"INDEX_WARM_MODULES"`
+
+	// If set, version info and version lists are cached in a server speaking
+	// the Redis protocol, shared between replicas.
+	RedisAddr     string `env:"REDIS_ADDR"`
+	RedisPassword string `env:"REDIS_PASSWORD"`
+	RedisDB       int    `env:"REDIS_DB,default=0"`
+
+	// Artifact storage. If STORAGE_DIR or S3_BUCKET is set, .mod and .zip
+	// files are fetched from upstream once and served by the proxy itself.
+	StorageDir  string `env:"STORAGE_DIR"`
+	S3Endpoint  string `env:"S3_ENDPOINT,default=s3.amazonaws.com"`
+	S3Bucket    string `env:"S3_BUCKET"`
+	S3Prefix    string `env:"S3_PREFIX"`
+	S3AccessKey string `env:"S3_ACCESS_KEY"`
+	S3SecretKey string `env:"S3_SECRET_KEY"`
+	S3Insecure  bool   `env:"S3_INSECURE,default=false"`
 }{}))
 
 // parseDuration extends time.ParseDuration to support days (d), months (M), and years (y).
@@ -80,6 +170,18 @@ func parseDuration(s string) (time.Duration, error) {
 	return total, nil
 }
 
+// parseCutoff parses an absolute cutoff, either an RFC 3339 timestamp or a
+// date, which is taken to be midnight UTC.
+func parseCutoff(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid cutoff: %s", s)
+}
+
 func main() {
 	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})))
 	ctx := context.Background()
@@ -95,16 +197,222 @@ func main() {
 		log.FatalContext(ctx, "failed to create cache", "error", err)
 	}
 
-	defaultCooldown, err := parseDuration(cfg.DefaultCooldown)
+	listCacheTTL, err := time.ParseDuration(cfg.ListCacheTTL)
+	if err != nil {
+		log.FatalContext(ctx, "invalid list cache TTL", "error", err)
+	}
+	listCache := expirable.NewLRU[string, *upstreamResponse](cfg.ListCacheSize, nil, listCacheTTL)
+
+	var infoStore, listStore cacheStore
+	switch {
+	case cfg.CacheDir != "" && cfg.RedisAddr != "":
+		log.FatalContext(ctx, "CACHE_DIR and REDIS_ADDR are mutually exclusive")
+	case cfg.CacheDir != "":
+		c, err := openBoltCache(cfg.CacheDir)
+		if err != nil {
+			log.FatalContext(ctx, "failed to open persistent cache", "error", err)
+		}
+		defer c.Close()
+		infoStore = c
+		log.InfoContext(ctx, "persisting version info", "dir", cfg.CacheDir)
+	case cfg.RedisAddr != "":
+		client, err := newRedisClient(ctx, cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+		if err != nil {
+			log.FatalContext(ctx, "failed to connect to redis", "error", err)
+		}
+		defer client.Close()
+		infoStore = &redisCache{client: client, prefix: "info:"}
+		listStore = &redisCache{client: client, prefix: "list:", ttl: listCacheTTL}
+		log.InfoContext(ctx, "sharing caches via redis", "addr", cfg.RedisAddr)
+	}
+
+	tileCache, err := lru.New[string, []byte](cfg.TileCacheSize)
+	if err != nil {
+		log.FatalContext(ctx, "failed to create tile cache", "error", err)
+	}
+
+	retractCache, err := lru.New[string, []modfile.VersionInterval](cfg.RetractCacheSize)
+	if err != nil {
+		log.FatalContext(ctx, "failed to create retraction cache", "error", err)
+	}
+
+	goModCache, err := lru.New[string, bool](cfg.GoModCacheSize)
+	if err != nil {
+		log.FatalContext(ctx, "failed to create go.mod cache", "error", err)
+	}
+
+	moduleAgeCache, err := lru.New[string, time.Time](cfg.ModuleAgeCacheSize)
+	if err != nil {
+		log.FatalContext(ctx, "failed to create module age cache", "error", err)
+	}
+
+	defaultCooldown, err := parseCooldown(cfg.DefaultCooldown)
 	if err != nil {
 		log.FatalContext(ctx, "invalid default cooldown duration", "error", err)
 	}
 
+	cal, err := loadCalendar(cfg.HolidaysFile, cfg.CalendarTimezone)
+	if err != nil {
+		log.FatalContext(ctx, "invalid calendar", "error", err)
+	}
+
+	var prerelease *prereleasePolicy
+	if cfg.PrereleaseCooldown != "" {
+		pre, err := parsePrereleasePolicy(cfg.PrereleaseCooldown)
+		if err != nil {
+			log.FatalContext(ctx, "invalid prerelease cooldown", "error", err)
+		}
+		prerelease = &pre
+	}
+
+	var pseudoVersions *pseudoVersionPolicy
+	if cfg.PseudoVersions != "" {
+		pv, err := parsePseudoVersionPolicy(cfg.PseudoVersions)
+		if err != nil {
+			log.FatalContext(ctx, "invalid pseudo-version policy", "error", err)
+		}
+		pseudoVersions = &pv
+	}
+
+	var quarantine *Cooldown
+	if cfg.NewModuleQuarantine != "" {
+		c, err := parseCooldown(cfg.NewModuleQuarantine)
+		if err != nil {
+			log.FatalContext(ctx, "invalid new module quarantine", "error", err)
+		}
+		quarantine = &c
+	}
+
+	var tiers tieredCooldowns
+	for t, s := range [numTiers]string{cfg.PatchCooldown, cfg.MinorCooldown, cfg.MajorCooldown} {
+		if s == "" {
+			continue
+		}
+		c, err := parseCooldown(s)
+		if err != nil {
+			log.FatalContext(ctx, "invalid tiered cooldown", "tier", changeTier(t), "error", err)
+		}
+		tiers[t] = &c
+	}
+
+	listTimeout, err := time.ParseDuration(cfg.ListTimeout)
+	if err != nil {
+		log.FatalContext(ctx, "invalid list timeout", "error", err)
+	}
+
+	var storage Storage
+	switch {
+	case cfg.StorageDir != "" && cfg.S3Bucket != "":
+		log.FatalContext(ctx, "STORAGE_DIR and S3_BUCKET are mutually exclusive")
+	case cfg.StorageDir != "":
+		if storage, err = newFileStorage(cfg.StorageDir); err != nil {
+			log.FatalContext(ctx, "failed to create file storage", "error", err)
+		}
+		log.InfoContext(ctx, "storing artifacts on disk", "dir", cfg.StorageDir)
+	case cfg.S3Bucket != "":
+		if storage, err = newS3Storage(cfg.S3Endpoint, cfg.S3Bucket, cfg.S3Prefix, cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3Insecure); err != nil {
+			log.FatalContext(ctx, "failed to create S3 storage", "error", err)
+		}
+		log.InfoContext(ctx, "storing artifacts in S3", "endpoint", cfg.S3Endpoint, "bucket", cfg.S3Bucket)
+	}
+
+	var firstSeen *firstSeenStore
+	if cfg.FirstSeenDB != "" {
+		if firstSeen, err = openFirstSeenStore(cfg.FirstSeenDB); err != nil {
+			log.FatalContext(ctx, "failed to open first-seen database", "error", err)
+		}
+		defer firstSeen.Close()
+		log.InfoContext(ctx, "tracking first-seen times", "path", cfg.FirstSeenDB)
+		if cfg.IndexURL == "" {
+			log.WarnContext(ctx, "FIRST_SEEN_DB without INDEX_URL treats every version new to this proxy as brand new, however old")
+		}
+	}
+
 	proxy := &Proxy{
 		upstream:        cfg.UpstreamProxy,
 		client:          &http.Client{Timeout: 30 * time.Second},
 		cache:           cache,
+		infoStore:       infoStore,
+		listCache:       listCache,
+		listStore:       listStore,
+		tileCache:       tileCache,
+		retractCache:    retractCache,
+		goModCache:      goModCache,
+		moduleAgeCache:  moduleAgeCache,
 		defaultCooldown: defaultCooldown,
+		calendar:        cal,
+		prerelease:      prerelease,
+		pseudoVersions:  pseudoVersions,
+		tiers:           tiers,
+		quarantine:      quarantine,
+		listConcurrency: cfg.ListConcurrency,
+		listTimeout:     listTimeout,
+		storage:         storage,
+		firstSeen:       firstSeen,
+
+		verifyPseudoVersions: cfg.VerifyPseudoVersions,
+		quarantineExempt:     cfg.NewModuleQuarantineExempt,
+	}
+
+	if cfg.PolicyFile != "" {
+		if err := proxy.reloadPolicy(ctx, cfg.PolicyFile); err != nil {
+			log.FatalContext(ctx, "invalid policy", "error", err)
+		}
+		if err := proxy.watchPolicy(ctx, cfg.PolicyFile); err != nil {
+			log.FatalContext(ctx, "failed to watch policy", "error", err)
+		}
+	}
+
+	if cfg.OverridesFile != "" {
+		overrides, err := loadOverrides(cfg.OverridesFile)
+		if err != nil {
+			log.FatalContext(ctx, "invalid overrides", "error", err)
+		}
+		proxy.overrides = overrides
+		log.InfoContext(ctx, "loaded overrides", "path", cfg.OverridesFile, "entries", len(overrides.entries))
+	}
+
+	if cfg.VulnDB != "" {
+		vulns, err := loadVulnDB(cfg.VulnDB)
+		if err != nil {
+			log.FatalContext(ctx, "invalid vulnerability database", "error", err)
+		}
+		proxy.vulns = vulns
+		log.InfoContext(ctx, "loaded vulnerability database", "path", cfg.VulnDB, "advisories", vulns.Advisories())
+	}
+	if cfg.VulnBlock {
+		if proxy.vulns == nil {
+			log.FatalContext(ctx, "VULN_BLOCK requires VULN_DB")
+		}
+		proxy.blockVulnerable = true
+		if cfg.VulnBlockSeverity != "" {
+			severity, err := parseSeverity(cfg.VulnBlockSeverity)
+			if err != nil {
+				log.FatalContext(ctx, "invalid VULN_BLOCK_SEVERITY", "error", err)
+			}
+			proxy.blockSeverity = severity
+		}
+	}
+
+	if cfg.IndexURL != "" {
+		if firstSeen == nil {
+			log.FatalContext(ctx, "INDEX_URL requires FIRST_SEEN_DB")
+		}
+		interval, err := time.ParseDuration(cfg.IndexPollInterval)
+		if err != nil {
+			log.FatalContext(ctx, "invalid index poll interval", "error", err)
+		}
+		poller := &indexPoller{
+			url:      cfg.IndexURL,
+			client:   &http.Client{Timeout: 30 * time.Second},
+			store:    firstSeen,
+			interval: interval,
+			limit:    cfg.IndexPageSize,
+			warm:     cfg.IndexWarmModules,
+			proxy:    proxy,
+		}
+		log.InfoContext(ctx, "consuming module index", "url", cfg.IndexURL, "interval", interval)
+		go poller.Run(ctx)
 	}
 
 	http.HandleFunc("/", proxy.ServeHTTP)
@@ -120,7 +428,60 @@ type Proxy struct {
 	upstream        string
 	client          *http.Client
 	cache           *lru.Cache[string, *VersionInfo]
-	defaultCooldown time.Duration
+	infoStore       cacheStore // persistent or shared tier behind cache, may be nil
+	listCache       *expirable.LRU[string, *upstreamResponse]
+	listStore       cacheStore                                    // shared tier behind listCache, may be nil
+	tileCache       *lru.Cache[string, []byte]                    // checksum database tiles, keyed by path
+	retractCache    *lru.Cache[string, []modfile.VersionInterval] // retractions, keyed by module@version
+	goModCache      *lru.Cache[string, bool]                      // whether versions have a go.mod, keyed by module@version
+	moduleAgeCache  *lru.Cache[string, time.Time]                 // earliest version times, keyed by module
+	defaultCooldown Cooldown
+	calendar        *calendar            // for business days and months in cooldowns, may be nil
+	prerelease      *prereleasePolicy    // how prereleases are treated, may be nil
+	pseudoVersions  *pseudoVersionPolicy // how pseudo-versions are treated, may be nil
+	tiers           tieredCooldowns      // cooldowns for patch, minor and major updates
+
+	// quarantine, if set, is how old a module's earliest version must be
+	// before any version of it is served, except for modules under
+	// quarantineExempt.
+	quarantine       *Cooldown
+	quarantineExempt []string
+
+	// policy, if set, sets per-module cooldowns. It's swapped atomically
+	// when the policy file is reloaded.
+	policy atomic.Pointer[Policy]
+
+	// overrides, if non-nil, allows or denies specific versions regardless
+	// of their cooldown.
+	overrides *Overrides
+
+	// vulns, if non-nil, is used to let security fixes skip the cooldown.
+	vulns *vulnDB
+
+	// blockVulnerable hides versions affected by advisories in vulns of at
+	// least blockSeverity, regardless of their age.
+	blockVulnerable bool
+	blockSeverity   vulnSeverity
+
+	// listConcurrency bounds the number of concurrent .info fetches made to
+	// filter a version list, and listTimeout bounds their total duration.
+	listConcurrency int
+	listTimeout     time.Duration
+
+	// firstSeen, if non-nil, records when each version was first observed,
+	// so that backdated commit times can't skip the cooldown.
+	firstSeen *firstSeenStore
+
+	// verifyPseudoVersions makes the proxy check a pseudo-version's embedded
+	// timestamp against upstream, instead of trusting it on its own.
+	verifyPseudoVersions bool
+
+	// inflight coalesces concurrent upstream fetches of the same resource.
+	inflight singleflight.Group
+
+	// storage, if non-nil, holds .mod and .zip files served by the proxy.
+	// If nil, those requests are redirected to upstream.
+	storage Storage
 }
 
 func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -128,23 +489,41 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log := clog.FromContext(ctx)
 	log.InfoContext(ctx, "request", "path", r.URL.Path)
 
-	// Try to extract cooldown from first path segment
-	var cooldown time.Duration
-	var cooldownStr string
-
-	pathParts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
-	if len(pathParts) >= 2 {
+	// Load the policy once, so a concurrent reload can't change it mid-request
+	policy := p.policy.Load()
+
+	// Try to extract cooldown from first path segment, or an absolute
+	// cutoff from /at/<time>/
+	now := time.Now()
+	var cutoff time.Time
+	var cooldown string // canonical form of the cooldown or cutoff
+	var cooldownPrefix string
+
+	pathParts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+	if len(pathParts) >= 3 && pathParts[0] == "at" {
+		if at, err := parseCutoff(pathParts[1]); err == nil {
+			// A cutoff in the future is no cooldown at all
+			cutoff = at
+			if cutoff.After(now) {
+				cutoff = now
+			}
+			cooldown = at.UTC().Format(time.RFC3339)
+			cooldownPrefix = "/at/" + pathParts[1] + "/"
+		}
+	} else if len(pathParts) >= 2 {
 		// Try to parse first segment as duration
-		if d, err := parseDuration(pathParts[0]); err == nil {
+		if c, err := parseCooldown(pathParts[0]); err == nil {
 			// Valid duration found
-			cooldown = d
-			cooldownStr = pathParts[0]
+			cutoff = c.Cutoff(now, p.calendar)
+			cooldown = c.String()
+			cooldownPrefix = "/" + pathParts[0] + "/"
 		}
 	}
 
 	// If no valid duration prefix, use default
-	if cooldownStr == "" {
-		cooldown = p.defaultCooldown
+	if cooldownPrefix == "" {
+		cutoff = p.defaultCooldown.Cutoff(now, p.calendar)
+		cooldown = p.defaultCooldown.String()
 	}
 
 	// Parse the path to determine the request type
@@ -154,22 +533,46 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// /<module>/@v/<version>.mod
 	// /<module>/@v/<version>.zip
 	// /<module>/@latest
+	// /sumdb/<name>/...
 
-	// Strip the cooldown prefix from the path if present
+	// Strip the cooldown prefix from the path if present, e.g. /14d/ or
+	// /at/2025-06-01/
 	path := r.URL.Path
-	if cooldownStr != "" {
-		cooldownPrefix := "/" + cooldownStr + "/"
+	if cooldownPrefix != "" {
 		path = strings.TrimPrefix(r.URL.Path, cooldownPrefix)
 		if !strings.HasPrefix(path, "/") {
 			path = "/" + path
 		}
 	}
 
-	// Check for @latest first
+	// Options follow the cooldown prefix
+	path, opts, err := parseOptions(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// check returns the versionCheck for a request for modulePath
+	check := func(modulePath string) *versionCheck {
+		modCutoff, modCooldown := p.effectiveCutoff(policy, modulePath, now, cutoff, cooldown, cooldownPrefix != "", p.defaultCooldown)
+		vc := newVersionCheck(w, r, now, modCutoff, modCooldown, policy, modulePath)
+		vc.prereleaseCutoff, vc.excludePrereleases = p.prereleaseCutoff(now, modCutoff, opts.prerelease)
+		vc.forbidPseudo, vc.requireBase, vc.pseudoCutoff = p.pseudoVersionRules(now, opts.pseudo)
+		vc.tiers = p.tierCutoffs(policy, modulePath, now, cutoff, cooldown, cooldownPrefix != "", opts)
+		return vc
+	}
+
+	// Checksum database requests aren't subject to the cooldown
+	if strings.HasPrefix(path, "/sumdb/") {
+		p.handleSumdb(ctx, w, path)
+		return
+	}
+
+	// Check for @latest
 	if strings.HasSuffix(path, "/@latest") {
 		modulePath := strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/@latest")
 		log = log.With("module", modulePath)
-		p.handleLatest(ctx, cooldown, w, r, modulePath)
+		p.handleLatest(ctx, check(modulePath), w, r, modulePath)
 		return
 	}
 
@@ -184,74 +587,97 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	versionPath := parts[1]
 
 	log = log.With("module", modulePath, "version_path", versionPath)
+	vc := check(modulePath)
 
 	// Handle different request types
 	switch {
 	case versionPath == "list":
 		// Filter version list
-		p.handleList(ctx, cooldown, w, modulePath)
+		p.handleList(ctx, vc, w, modulePath)
 	case strings.HasSuffix(versionPath, ".info"):
 		// Check if version is within cooldown
 		version := strings.TrimSuffix(versionPath, ".info")
-		p.handleInfo(ctx, cooldown, w, modulePath, version)
+		p.handleInfo(ctx, vc, w, modulePath, version)
 	case strings.HasSuffix(versionPath, ".mod"), strings.HasSuffix(versionPath, ".zip"):
-		// Redirect to upstream
-		p.redirectToUpstream(ctx, w, path)
+		// Check cooldown, then redirect to upstream
+		version := strings.TrimSuffix(strings.TrimSuffix(versionPath, ".mod"), ".zip")
+		p.handleDownload(ctx, vc, w, path, modulePath, version)
 	default:
 		// Unknown request type, proxy directly
 		p.proxyRequest(ctx, w, path)
 	}
 }
 
-func (p *Proxy) handleList(ctx context.Context, cooldown time.Duration, w http.ResponseWriter, modulePath string) {
+// effectiveCutoff returns the cutoff to apply to modulePath at now, and the
+// cooldown it comes from, given the cutoff and cooldown from the URL prefix
+// (or the default, if fromURL is false).
+//
+// Without a policy, that's used as-is. With one, the policy's cooldown for
+// the module applies (or def, if no pattern matches), and a URL prefix can
+// tighten it but never loosen it.
+func (p *Proxy) effectiveCutoff(policy *Policy, modulePath string, now, cutoff time.Time, cooldown string, fromURL bool, def Cooldown) (time.Time, string) {
+	if policy == nil {
+		return cutoff, cooldown
+	}
+	base, ok := policy.Cooldown(modulePath)
+	if !ok {
+		base = def
+	}
+	baseCutoff := base.Cutoff(now, p.calendar)
+	if fromURL && cutoff.Before(baseCutoff) {
+		return cutoff, cooldown
+	}
+	return baseCutoff, base.String()
+}
+
+func (p *Proxy) handleList(ctx context.Context, vc *versionCheck, w http.ResponseWriter, modulePath string) {
 	log := clog.FromContext(ctx)
 
 	// Fetch the version list from upstream
-	upstreamURL := fmt.Sprintf("%s/%s/@v/list", p.upstream, modulePath)
-	resp, err := p.client.Get(upstreamURL)
+	resp, err := p.fetchList(ctx, modulePath)
 	if err != nil {
 		log.ErrorContext(ctx, "failed to fetch version list", "error", err)
 		http.Error(w, "failed to fetch version list", http.StatusBadGateway)
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		log.WarnContext(ctx, "upstream returned non-200", "status", resp.StatusCode)
-		w.WriteHeader(resp.StatusCode)
-		io.Copy(w, resp.Body)
+	if resp.status != http.StatusOK {
+		log.WarnContext(ctx, "upstream returned non-200", "status", resp.status)
+		w.WriteHeader(resp.status)
+		w.Write(resp.body)
 		return
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.ErrorContext(ctx, "failed to read response", "error", err)
-		http.Error(w, "failed to read response", http.StatusInternalServerError)
-		return
-	}
-
-	versions := strings.Split(strings.TrimSpace(string(body)), "\n")
+	versions := strings.Split(strings.TrimSpace(string(resp.body)), "\n")
 	filteredVersions := []string{}
 
-	cutoffTime := time.Now().Add(-cooldown)
+	// Fetch .info for each version to check timestamp (with caching)
+	infos := p.fetchVersionInfos(ctx, modulePath, versions)
 
-	for _, version := range versions {
-		if version == "" {
+	for i, ok := range p.eligibleVersions(ctx, vc, versions, infos) {
+		version := versions[i]
+		if infos[i] == nil {
 			continue
 		}
 
-		// Fetch .info for each version to check timestamp (with caching)
-		info, err := p.fetchVersionInfo(ctx, modulePath, version)
-		if err != nil {
-			log.WarnContext(ctx, "failed to fetch version info, skipping", "version", version, "error", err)
-			continue
-		}
-
-		if info.Time.Before(cutoffTime) || info.Time.Equal(cutoffTime) {
+		if ok {
 			filteredVersions = append(filteredVersions, version)
-			log.DebugContext(ctx, "version included", "version", version, "time", info.Time)
+			log.DebugContext(ctx, "version included", "version", version, "time", infos[i].Time)
 		} else {
-			log.InfoContext(ctx, "version filtered out", "version", version, "time", info.Time, "cutoff", cutoffTime)
+			log.InfoContext(ctx, "version filtered out", "version", version)
+		}
+	}
+
+	// Drop versions retracted by the latest eligible version
+	if candidates := p.latestCandidates(ctx, modulePath, filteredVersions); len(candidates) > 0 {
+		if retracted := p.retractions(ctx, modulePath, candidates[0]); len(retracted) > 0 {
+			filteredVersions = slices.DeleteFunc(filteredVersions, func(version string) bool {
+				if isRetracted(retracted, version) {
+					log.InfoContext(ctx, "version retracted", "version", version, "by", candidates[0])
+					return true
+				}
+				return false
+			})
 		}
 	}
 
@@ -262,108 +688,129 @@ func (p *Proxy) handleList(ctx context.Context, cooldown time.Duration, w http.R
 	}
 }
 
-func (p *Proxy) handleInfo(ctx context.Context, cooldown time.Duration, w http.ResponseWriter, modulePath, version string) {
+func (p *Proxy) handleInfo(ctx context.Context, vc *versionCheck, w http.ResponseWriter, modulePath, version string) {
 	log := clog.FromContext(ctx)
 
 	// Fetch .info from upstream (with caching)
 	info, err := p.fetchVersionInfo(ctx, modulePath, version)
 	if err != nil {
-		log.ErrorContext(ctx, "failed to fetch version info", "error", err)
-		http.Error(w, "failed to fetch version info", http.StatusBadGateway)
+		versionInfoError(ctx, w, err)
+		return
+	}
+
+	previous, err := p.previousEligible(ctx, vc, modulePath, version)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to find previous eligible version", "error", err)
+		http.Error(w, "failed to fetch version list", http.StatusBadGateway)
 		return
 	}
 
-	cutoffTime := time.Now().Add(-cooldown)
-	if info.Time.After(cutoffTime) {
-		log.InfoContext(ctx, "version too new", "version", version, "time", info.Time, "cutoff", cutoffTime)
+	if !p.eligible(ctx, vc, previous, version, info) {
 		http.Error(w, "version not found", http.StatusNotFound)
 		return
 	}
 
+	// Unverified pseudo-version info is made up from the version itself, so
+	// what's served has to come from upstream, which knows if it exists.
+	if !p.verifyPseudoVersions && module.IsPseudoVersion(version) {
+		if info, err = p.fetchUpstreamVersionInfo(ctx, modulePath, version); err != nil {
+			versionInfoError(ctx, w, err)
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(info)
 }
 
-func (p *Proxy) handleLatest(ctx context.Context, cooldown time.Duration, w http.ResponseWriter, r *http.Request, modulePath string) {
+func (p *Proxy) handleLatest(ctx context.Context, vc *versionCheck, w http.ResponseWriter, r *http.Request, modulePath string) {
 	log := clog.FromContext(ctx)
 
 	// Fetch @latest from upstream
-	latestURL := fmt.Sprintf("%s/%s/@latest", p.upstream, modulePath)
-	resp, err := p.client.Get(latestURL)
+	resp, err := p.fetchUpstream(ctx, fmt.Sprintf("/%s/@latest", modulePath))
 	if err != nil {
 		log.ErrorContext(ctx, "failed to fetch latest", "error", err)
 		http.Error(w, "failed to fetch latest", http.StatusBadGateway)
 		return
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		log.WarnContext(ctx, "upstream returned non-200", "status", resp.StatusCode)
-		w.WriteHeader(resp.StatusCode)
-		io.Copy(w, resp.Body)
-		return
-	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.ErrorContext(ctx, "failed to read response", "error", err)
-		http.Error(w, "failed to read response", http.StatusInternalServerError)
+	if resp.status != http.StatusOK {
+		log.WarnContext(ctx, "upstream returned non-200", "status", resp.status)
+		w.WriteHeader(resp.status)
+		w.Write(resp.body)
 		return
 	}
 
 	var info VersionInfo
-	if err := json.Unmarshal(body, &info); err != nil {
+	if err := json.Unmarshal(resp.body, &info); err != nil {
 		log.ErrorContext(ctx, "failed to parse latest info", "error", err)
 		http.Error(w, "failed to parse latest info", http.StatusInternalServerError)
 		return
 	}
 
-	cutoffTime := time.Now().Add(-cooldown)
-	if info.Time.After(cutoffTime) {
+	// With tiered cooldowns, versions are classified against the previous
+	// eligible version, so the whole list is checked once up front.
+	var walk *tierWalk
+	if vc.tiers != nil {
+		if walk, err = p.walkTiers(ctx, vc, modulePath, ""); err != nil {
+			log.ErrorContext(ctx, "failed to check version list", "error", err)
+			http.Error(w, "failed to fetch version list", http.StatusBadGateway)
+			return
+		}
+	}
+
+	// Retractions come from the first eligible version considered, which is
+	// the latest eligible version.
+	var retracted []modfile.VersionInterval
+	var retractedLoaded bool
+	eligible := func(version string, info *VersionInfo) bool {
+		if !p.eligible(ctx, vc, walk.previous(version), version, info) {
+			return false
+		}
+		if !retractedLoaded {
+			retracted = p.retractions(ctx, modulePath, version)
+			retractedLoaded = true
+		}
+		if isRetracted(retracted, version) {
+			log.InfoContext(ctx, "version retracted", "version", version)
+			return false
+		}
+		return true
+	}
+
+	if !eligible(info.Version, &info) {
 		// Latest is too new, need to find the most recent version that's old enough
-		log.InfoContext(ctx, "latest version too new, searching for older version", "latest_time", info.Time, "cutoff", cutoffTime)
+		log.InfoContext(ctx, "latest version not eligible, searching for older version", "latest", info.Version, "latest_time", info.Time)
 
 		// Fetch the version list and find the newest version within cooldown
-		listURL := fmt.Sprintf("%s/%s/@v/list", p.upstream, modulePath)
-		listResp, err := p.client.Get(listURL)
+		listResp, err := p.fetchList(ctx, modulePath)
 		if err != nil {
 			log.ErrorContext(ctx, "failed to fetch version list", "error", err)
 			http.Error(w, "failed to fetch version list", http.StatusBadGateway)
 			return
 		}
-		defer listResp.Body.Close()
 
-		if listResp.StatusCode != http.StatusOK {
-			log.WarnContext(ctx, "upstream list returned non-200", "status", listResp.StatusCode)
-			w.WriteHeader(listResp.StatusCode)
-			io.Copy(w, listResp.Body)
-			return
-		}
-
-		listBody, err := io.ReadAll(listResp.Body)
-		if err != nil {
-			log.ErrorContext(ctx, "failed to read list response", "error", err)
-			http.Error(w, "failed to read list response", http.StatusInternalServerError)
+		if listResp.status != http.StatusOK {
+			log.WarnContext(ctx, "upstream list returned non-200", "status", listResp.status)
+			w.WriteHeader(listResp.status)
+			w.Write(listResp.body)
 			return
 		}
 
-		versions := strings.Split(strings.TrimSpace(string(listBody)), "\n")
+		versions := strings.Split(strings.TrimSpace(string(listResp.body)), "\n")
 
+		// Walk versions in the order the go command would prefer them, since
+		// the list isn't guaranteed to be sorted.
 		var latestOldEnough *VersionInfo
-		for i := len(versions) - 1; i >= 0; i-- {
-			version := strings.TrimSpace(versions[i])
-			if version == "" {
-				continue
-			}
-
+		for _, version := range p.latestCandidates(ctx, modulePath, versions) {
 			versionInfo, err := p.fetchVersionInfo(ctx, modulePath, version)
 			if err != nil {
 				log.WarnContext(ctx, "failed to fetch version info", "version", version, "error", err)
 				continue
 			}
 
-			if versionInfo.Time.Before(cutoffTime) || versionInfo.Time.Equal(cutoffTime) {
+			if eligible(version, versionInfo) {
 				latestOldEnough = versionInfo
 				break
 			}
@@ -383,6 +830,103 @@ func (p *Proxy) handleLatest(ctx context.Context, cooldown time.Duration, w http
 	json.NewEncoder(w).Encode(info)
 }
 
+// handleDownload enforces the cooldown for .mod and .zip requests, so that
+// pinning an exact version can't bypass the filtering done by list and info.
+func (p *Proxy) handleDownload(ctx context.Context, vc *versionCheck, w http.ResponseWriter, path, modulePath, version string) {
+	log := clog.FromContext(ctx)
+
+	// Fetch .info from upstream (with caching)
+	info, err := p.fetchVersionInfo(ctx, modulePath, version)
+	if err != nil {
+		versionInfoError(ctx, w, err)
+		return
+	}
+
+	previous, err := p.previousEligible(ctx, vc, modulePath, version)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to find previous eligible version", "error", err)
+		http.Error(w, "failed to fetch version list", http.StatusBadGateway)
+		return
+	}
+
+	if !p.eligible(ctx, vc, previous, version, info) {
+		http.Error(w, "version not found", http.StatusNotFound)
+		return
+	}
+
+	if p.storage == nil {
+		p.redirectToUpstream(ctx, w, path)
+		return
+	}
+	p.serveFromStorage(ctx, w, path)
+}
+
+// serveFromStorage serves a .mod or .zip file from storage, fetching it from
+// upstream and storing it first if necessary.
+func (p *Proxy) serveFromStorage(ctx context.Context, w http.ResponseWriter, path string) {
+	log := clog.FromContext(ctx)
+	key := strings.TrimPrefix(path, "/")
+
+	rc, err := p.storage.Get(ctx, key)
+	if isNotExist(err) {
+		log.DebugContext(ctx, "storage miss", "key", key)
+		if !p.fetchToStorage(ctx, w, path, key) {
+			return
+		}
+		rc, err = p.storage.Get(ctx, key)
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to read from storage", "error", err)
+		http.Error(w, "failed to read from storage", http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+
+	if strings.HasSuffix(path, ".zip") {
+		w.Header().Set("Content-Type", "application/zip")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, rc)
+}
+
+// fetchToStorage copies an artifact from upstream into storage. On failure it
+// writes an error response and returns false.
+func (p *Proxy) fetchToStorage(ctx context.Context, w http.ResponseWriter, path, key string) bool {
+	log := clog.FromContext(ctx)
+
+	upstreamURL := p.upstream + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstreamURL, nil)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to create request", "error", err)
+		http.Error(w, "failed to fetch from upstream", http.StatusBadGateway)
+		return false
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to fetch from upstream", "error", err)
+		http.Error(w, "failed to fetch from upstream", http.StatusBadGateway)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.WarnContext(ctx, "upstream returned non-200", "status", resp.StatusCode)
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+		return false
+	}
+
+	if err := p.storage.Put(ctx, key, resp.Body, resp.ContentLength); err != nil {
+		log.ErrorContext(ctx, "failed to store artifact", "error", err)
+		http.Error(w, "failed to store artifact", http.StatusInternalServerError)
+		return false
+	}
+	log.InfoContext(ctx, "stored artifact", "key", key)
+	return true
+}
+
 func (p *Proxy) redirectToUpstream(ctx context.Context, w http.ResponseWriter, path string) {
 	log := clog.FromContext(ctx)
 
@@ -412,13 +956,138 @@ func (p *Proxy) proxyRequest(ctx context.Context, w http.ResponseWriter, path st
 	io.Copy(w, resp.Body)
 }
 
+// upstreamStatusError is returned when upstream answers a request with a
+// status other than 200.
+type upstreamStatusError struct {
+	status int
+}
+
+func (e *upstreamStatusError) Error() string {
+	return fmt.Sprintf("upstream returned status %d", e.status)
+}
+
+// versionInfoError responds to a request whose version info couldn't be
+// fetched. Upstream's 404 and 410 are passed through, so the go command
+// knows the version doesn't exist and can fall through to the next proxy in
+// GOPROXY; anything else is a bad gateway.
+func versionInfoError(ctx context.Context, w http.ResponseWriter, err error) {
+	log := clog.FromContext(ctx)
+	var statusErr *upstreamStatusError
+	if errors.As(err, &statusErr) && (statusErr.status == http.StatusNotFound || statusErr.status == http.StatusGone) {
+		log.WarnContext(ctx, "upstream returned non-200", "status", statusErr.status)
+		http.Error(w, "version not found", statusErr.status)
+		return
+	}
+	log.ErrorContext(ctx, "failed to fetch version info", "error", err)
+	http.Error(w, "failed to fetch version info", http.StatusBadGateway)
+}
+
 type VersionInfo struct {
 	Version string    `json:"Version"`
 	Time    time.Time `json:"Time"`
 }
 
+// versionTime returns the time a version's cooldown is measured from. That's
+// the upstream commit time, or if the first-seen clock is enabled, the later
+// of that and when the proxy first observed the version.
+func (p *Proxy) versionTime(ctx context.Context, modulePath, version string, info *VersionInfo) time.Time {
+	if p.firstSeen == nil {
+		return info.Time
+	}
+
+	// The store is keyed by unescaped paths and versions, as the module
+	// index reports them, but requests use escaped ones.
+	if unescaped, err := module.UnescapePath(modulePath); err == nil {
+		modulePath = unescaped
+	}
+	if unescaped, err := module.UnescapeVersion(version); err == nil {
+		version = unescaped
+	}
+
+	seen, err := p.firstSeen.Observe(modulePath, version, time.Now())
+	if err != nil {
+		// Fail closed, treating the version as brand new
+		clog.FromContext(ctx).ErrorContext(ctx, "failed to record first-seen time", "version", version, "error", err)
+		return time.Now()
+	}
+	if seen.After(info.Time) {
+		return seen
+	}
+	return info.Time
+}
+
+// fetchVersionInfos fetches version info for each version concurrently, with
+// at most p.listConcurrency fetches in flight and an overall deadline of
+// p.listTimeout. The result is in the same order as versions; entries are nil
+// for empty versions and versions whose info couldn't be fetched.
+func (p *Proxy) fetchVersionInfos(ctx context.Context, modulePath string, versions []string) []*VersionInfo {
+	log := clog.FromContext(ctx)
+
+	if p.listTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.listTimeout)
+		defer cancel()
+	}
+
+	var g errgroup.Group
+	g.SetLimit(max(p.listConcurrency, 1))
+
+	infos := make([]*VersionInfo, len(versions))
+	for i, version := range versions {
+		if version == "" {
+			continue
+		}
+		g.Go(func() error {
+			info, err := p.fetchVersionInfo(ctx, modulePath, version)
+			if err != nil {
+				log.WarnContext(ctx, "failed to fetch version info, skipping", "version", version, "error", err)
+				return nil
+			}
+			infos[i] = info
+			return nil
+		})
+	}
+	g.Wait()
+
+	return infos
+}
+
 // fetchVersionInfo fetches version info with caching
 func (p *Proxy) fetchVersionInfo(ctx context.Context, modulePath, version string) (*VersionInfo, error) {
+	// Pseudo-versions carry their commit time, so there's no need to ask upstream
+	if t, err := module.PseudoVersionTime(version); err == nil {
+		return p.pseudoVersionInfo(ctx, modulePath, version, t)
+	}
+	return p.fetchUpstreamVersionInfo(ctx, modulePath, version)
+}
+
+// pseudoVersionInfo returns version info for a pseudo-version using the
+// timestamp t embedded in it. If p.verifyPseudoVersions is set, it's checked
+// against upstream, and the later of the two times wins. Otherwise it's only
+// fit for rejecting versions that are too new, since nothing has checked
+// that the version exists.
+func (p *Proxy) pseudoVersionInfo(ctx context.Context, modulePath, version string, t time.Time) (*VersionInfo, error) {
+	log := clog.FromContext(ctx)
+	local := &VersionInfo{Version: version, Time: t}
+	if !p.verifyPseudoVersions {
+		return local, nil
+	}
+
+	upstream, err := p.fetchUpstreamVersionInfo(ctx, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	if !upstream.Time.Equal(t) {
+		log.WarnContext(ctx, "pseudo-version time mismatch", "version", version, "local_time", t, "upstream_time", upstream.Time)
+		if upstream.Time.Before(t) {
+			return local, nil
+		}
+	}
+	return upstream, nil
+}
+
+// fetchUpstreamVersionInfo fetches version info from upstream with caching
+func (p *Proxy) fetchUpstreamVersionInfo(ctx context.Context, modulePath, version string) (*VersionInfo, error) {
 	log := clog.FromContext(ctx)
 	cacheKey := fmt.Sprintf("%s@%s", modulePath, version)
 
@@ -430,30 +1099,174 @@ func (p *Proxy) fetchVersionInfo(ctx context.Context, modulePath, version string
 
 	log.DebugContext(ctx, "cache miss", "module", modulePath, "version", version)
 
-	// Fetch from upstream
-	infoURL := fmt.Sprintf("%s/%s/@v/%s.info", p.upstream, modulePath, version)
-	resp, err := p.client.Get(infoURL)
+	// Concurrent misses for the same version share a single upstream fetch
+	return coalesce(ctx, &p.inflight, cacheKey, func(ctx context.Context) (*VersionInfo, error) {
+		if info := p.loadVersionInfo(ctx, cacheKey); info != nil {
+			p.cache.Add(cacheKey, info)
+			return info, nil
+		}
+
+		infoURL := fmt.Sprintf("%s/%s/@v/%s.info", p.upstream, modulePath, version)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, infoURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, &upstreamStatusError{status: resp.StatusCode}
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		var info VersionInfo
+		if err := json.Unmarshal(body, &info); err != nil {
+			return nil, fmt.Errorf("failed to parse: %w", err)
+		}
+
+		// Store in cache
+		p.cache.Add(cacheKey, &info)
+		p.storeVersionInfo(ctx, cacheKey, &info)
+
+		return &info, nil
+	})
+}
+
+// loadVersionInfo returns version info from the persistent cache, or nil if
+// there's no persistent cache or it doesn't have the key.
+func (p *Proxy) loadVersionInfo(ctx context.Context, key string) *VersionInfo {
+	if p.infoStore == nil {
+		return nil
+	}
+	log := clog.FromContext(ctx)
+
+	b, ok, err := p.infoStore.Get(ctx, key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch: %w", err)
+		log.WarnContext(ctx, "failed to read persistent cache", "key", key, "error", err)
+		return nil
+	}
+	if !ok {
+		return nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("upstream returned status %d", resp.StatusCode)
+	var info VersionInfo
+	if err := json.Unmarshal(b, &info); err != nil {
+		log.WarnContext(ctx, "failed to parse persistent cache entry", "key", key, "error", err)
+		return nil
 	}
+	log.DebugContext(ctx, "persistent cache hit", "key", key)
+	return &info
+}
 
-	body, err := io.ReadAll(resp.Body)
+// storeVersionInfo writes version info to the persistent cache, if any.
+func (p *Proxy) storeVersionInfo(ctx context.Context, key string, info *VersionInfo) {
+	if p.infoStore == nil {
+		return
+	}
+
+	b, err := json.Marshal(info)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return
 	}
+	if err := p.infoStore.Set(ctx, key, b); err != nil {
+		clog.FromContext(ctx).WarnContext(ctx, "failed to write persistent cache", "key", key, "error", err)
+	}
+}
 
-	var info VersionInfo
-	if err := json.Unmarshal(body, &info); err != nil {
-		return nil, fmt.Errorf("failed to parse: %w", err)
+// fetchList fetches a module's version list, checking the in-memory and
+// shared list caches first. Only successful responses are cached.
+func (p *Proxy) fetchList(ctx context.Context, modulePath string) (*upstreamResponse, error) {
+	log := clog.FromContext(ctx)
+
+	if p.listCache != nil {
+		if resp, ok := p.listCache.Get(modulePath); ok {
+			log.DebugContext(ctx, "list cache hit", "module", modulePath)
+			return resp, nil
+		}
+	}
+	if p.listStore != nil {
+		body, ok, err := p.listStore.Get(ctx, modulePath)
+		if err != nil {
+			log.WarnContext(ctx, "failed to read shared list cache", "module", modulePath, "error", err)
+		} else if ok {
+			log.DebugContext(ctx, "shared list cache hit", "module", modulePath)
+			resp := &upstreamResponse{status: http.StatusOK, body: body}
+			if p.listCache != nil {
+				p.listCache.Add(modulePath, resp)
+			}
+			return resp, nil
+		}
+	}
+
+	resp, err := p.fetchUpstream(ctx, fmt.Sprintf("/%s/@v/list", modulePath))
+	if err != nil || resp.status != http.StatusOK {
+		return resp, err
+	}
+	if p.listCache != nil {
+		p.listCache.Add(modulePath, resp)
+	}
+	if p.listStore != nil {
+		if err := p.listStore.Set(ctx, modulePath, resp.body); err != nil {
+			log.WarnContext(ctx, "failed to write shared list cache", "module", modulePath, "error", err)
+		}
 	}
+	return resp, nil
+}
 
-	// Store in cache
-	p.cache.Add(cacheKey, &info)
+// upstreamResponse is a buffered upstream response, which can be shared
+// between coalesced requests.
+type upstreamResponse struct {
+	status int
+	body   []byte
+}
 
-	return &info, nil
+// fetchUpstream fetches path from upstream, sharing a single fetch between
+// concurrent callers requesting the same path.
+func (p *Proxy) fetchUpstream(ctx context.Context, path string) (*upstreamResponse, error) {
+	return coalesce(ctx, &p.inflight, path, func(ctx context.Context) (*upstreamResponse, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.upstream+path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch: %w", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		return &upstreamResponse{status: resp.StatusCode, body: body}, nil
+	})
+}
+
+// coalesce calls fn at most once at a time per key, sharing its result with
+// every caller that arrives while it's in flight.
+//
+// fn runs detached from the caller's cancellation, since other callers may
+// be waiting on its result; a caller whose ctx is done stops waiting early.
+func coalesce[T any](ctx context.Context, g *singleflight.Group, key string, fn func(context.Context) (T, error)) (T, error) {
+	ch := g.DoChan(key, func() (any, error) {
+		return fn(context.WithoutCancel(ctx))
+	})
+	select {
+	case res := <-ch:
+		if res.Shared {
+			clog.FromContext(ctx).DebugContext(ctx, "shared upstream fetch", "key", key)
+		}
+		v, _ := res.Val.(T)
+		return v, res.Err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
 }
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// requestOptions are set by key=value path segments following the cooldown
+// prefix, like /14d/pre=60d/ or /14d/patch=3d/major=30d/.
+type requestOptions struct {
+	prerelease *prereleasePolicy
+	pseudo     *pseudoVersionPolicy
+	tiers      tieredCooldowns
+}
+
+// parseOptions parses the option segments at the start of path, and returns
+// the rest of the path. Module paths can't contain "=", so the first
+// segment without one ends the options.
+func parseOptions(path string) (string, requestOptions, error) {
+	var opts requestOptions
+	rest := strings.TrimPrefix(path, "/")
+	for {
+		segment, after, ok := strings.Cut(rest, "/")
+		if !ok {
+			break
+		}
+		key, value, ok := strings.Cut(segment, "=")
+		if !ok {
+			break
+		}
+		switch tier, isTier := parseTier(key); {
+		case key == "pre":
+			pre, err := parsePrereleasePolicy(value)
+			if err != nil {
+				return "", opts, fmt.Errorf("invalid option %s: %w", segment, err)
+			}
+			opts.prerelease = &pre
+		case key == "pseudo":
+			pv, err := parsePseudoVersionPolicy(value)
+			if err != nil {
+				return "", opts, fmt.Errorf("invalid option %s: %w", segment, err)
+			}
+			opts.pseudo = &pv
+		case isTier:
+			c, err := parseCooldown(value)
+			if err != nil {
+				return "", opts, fmt.Errorf("invalid option %s: %w", segment, err)
+			}
+			opts.tiers[tier] = &c
+		default:
+			return "", opts, fmt.Errorf("unknown option %s", key)
+		}
+		rest = after
+	}
+	return "/" + rest, opts, nil
+}
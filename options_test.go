@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOptions(t *testing.T) {
+	for _, tt := range []struct {
+		path     string
+		wantPath string
+		wantPre  *prereleasePolicy
+		wantTier tieredCooldowns
+		wantErr  bool
+	}{{
+		path:     "/example.com/module/@v/list",
+		wantPath: "/example.com/module/@v/list",
+	}, {
+		path:     "/pre=60d/example.com/module/@v/list",
+		wantPath: "/example.com/module/@v/list",
+		wantPre:  &prereleasePolicy{cooldown: fixedCooldown(60 * 24 * time.Hour)},
+	}, {
+		path:     "/pre=never/example.com/module/@latest",
+		wantPath: "/example.com/module/@latest",
+		wantPre:  &prereleasePolicy{exclude: true},
+	}, {
+		path:     "/patch=3d/major=P1M/pre=never/example.com/module/@v/list",
+		wantPath: "/example.com/module/@v/list",
+		wantPre:  &prereleasePolicy{exclude: true},
+		wantTier: tieredCooldowns{
+			tierPatch: ptr(fixedCooldown(3 * 24 * time.Hour)),
+			tierMajor: &Cooldown{months: 1},
+		},
+	}, {
+		path:    "/minor=soon/example.com/module/@v/list",
+		wantErr: true,
+	}, {
+		path:    "/pre=soon/example.com/module/@v/list",
+		wantErr: true,
+	}, {
+		path:    "/foo=bar/example.com/module/@v/list",
+		wantErr: true,
+	}} {
+		path, opts, err := parseOptions(tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseOptions(%q): expected error", tt.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseOptions(%q): %v", tt.path, err)
+			continue
+		}
+		if path != tt.wantPath {
+			t.Errorf("parseOptions(%q) path = %q, want %q", tt.path, path, tt.wantPath)
+		}
+		if (opts.prerelease == nil) != (tt.wantPre == nil) || opts.prerelease != nil && *opts.prerelease != *tt.wantPre {
+			t.Errorf("parseOptions(%q) prerelease = %+v, want %+v", tt.path, opts.prerelease, tt.wantPre)
+		}
+		for tier, want := range tt.wantTier {
+			if got := opts.tiers[tier]; (got == nil) != (want == nil) || got != nil && *got != *want {
+				t.Errorf("parseOptions(%q) %v cooldown = %v, want %v", tt.path, changeTier(tier), got, want)
+			}
+		}
+	}
+}
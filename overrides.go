@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"gopkg.in/yaml.v3"
+)
+
+// Overrides are explicit decisions about specific versions, which take
+// precedence over cooldowns and rules. They're loaded from a YAML file like:
+//
+//	overrides:
+//	  - version: example.com/module@v1.2.3
+//	    action: deny
+//	    reason: contains malware
+//	  - version: golang.org/x/net@v0.40.0
+//	    action: allow
+//	    reason: fixes CVE-2025-0001
+//	    expires: 2025-07-01
+//
+// Entries with an expiry stop applying once it has passed.
+type Overrides struct {
+	entries map[module.Version]override
+}
+
+type overrideAction string
+
+const (
+	overrideAllow overrideAction = "allow"
+	overrideDeny  overrideAction = "deny"
+)
+
+type overridesFile struct {
+	Overrides []override `yaml:"overrides"`
+}
+
+type override struct {
+	Version string         `yaml:"version"`
+	Action  overrideAction `yaml:"action"`
+	Reason  string         `yaml:"reason"`
+	Expires time.Time      `yaml:"expires"`
+}
+
+func loadOverrides(path string) (*Overrides, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overrides: %w", err)
+	}
+	return parseOverrides(b)
+}
+
+func parseOverrides(b []byte) (*Overrides, error) {
+	var f overridesFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse overrides: %w", err)
+	}
+
+	o := &Overrides{entries: make(map[module.Version]override, len(f.Overrides))}
+	for _, e := range f.Overrides {
+		path, version, ok := strings.Cut(e.Version, "@")
+		if !ok {
+			return nil, fmt.Errorf("override %q is not of the form module@version", e.Version)
+		}
+		if err := module.Check(path, version); err != nil {
+			return nil, fmt.Errorf("invalid override: %w", err)
+		}
+		mv := module.Version{Path: path, Version: version}
+		if e.Action != overrideAllow && e.Action != overrideDeny {
+			return nil, fmt.Errorf("override for %s: action must be %q or %q, not %q", e.Version, overrideAllow, overrideDeny, e.Action)
+		}
+		if e.Reason == "" {
+			return nil, fmt.Errorf("override for %s has no reason", e.Version)
+		}
+		if _, dup := o.entries[mv]; dup {
+			return nil, fmt.Errorf("duplicate override for %s", e.Version)
+		}
+		o.entries[mv] = e
+	}
+	return o, nil
+}
+
+// Lookup returns the unexpired override for modulePath@version, which may be
+// escaped as in proxy URLs, if there is one.
+func (o *Overrides) Lookup(modulePath, version string, now time.Time) (override, bool) {
+	if o == nil {
+		return override{}, false
+	}
+	if unescaped, err := module.UnescapePath(modulePath); err == nil {
+		modulePath = unescaped
+	}
+	if unescaped, err := module.UnescapeVersion(version); err == nil {
+		version = unescaped
+	}
+	e, ok := o.entries[module.Version{Path: modulePath, Version: version}]
+	if !ok || (!e.Expires.IsZero() && !now.Before(e.Expires)) {
+		return override{}, false
+	}
+	return e, true
+}
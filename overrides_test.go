@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const testOverrides = `
+overrides:
+  - version: example.com/module@v1.0.0
+    action: deny
+    reason: contains malware
+  - version: example.com/module@v1.2.0
+    action: allow
+    reason: fixes CVE-2025-0001
+  - version: github.com/Azure/sdk@v1.0.0
+    action: deny
+    reason: compromised
+  - version: example.com/module@v1.1.0
+    action: allow
+    reason: expired
+    expires: 2020-01-01
+`
+
+func TestOverridesLookup(t *testing.T) {
+	o, err := parseOverrides([]byte(testOverrides))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for _, tt := range []struct {
+		module, version string
+		want            overrideAction
+	}{
+		{"example.com/module", "v1.0.0", overrideDeny},
+		{"example.com/module", "v1.2.0", overrideAllow},
+		{"github.com/!azure/sdk", "v1.0.0", overrideDeny},
+		{"example.com/module", "v1.1.0", ""}, // expired
+		{"example.com/module", "v1.3.0", ""},
+		{"example.com/other", "v1.0.0", ""},
+	} {
+		got, ok := o.Lookup(tt.module, tt.version, now)
+		if !ok {
+			got.Action = ""
+		}
+		if got.Action != tt.want {
+			t.Errorf("Lookup(%s@%s) = %q, want %q", tt.module, tt.version, got.Action, tt.want)
+		}
+	}
+
+	// Expiry is exclusive
+	expires := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, ok := o.Lookup("example.com/module", "v1.1.0", expires.Add(-time.Second)); !ok {
+		t.Errorf("override should apply before it expires")
+	}
+	if _, ok := o.Lookup("example.com/module", "v1.1.0", expires); ok {
+		t.Errorf("override should not apply once it expires")
+	}
+}
+
+func TestParseOverridesErrors(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		input string
+	}{
+		{"invalid yaml", "overrides: {\n"},
+		{"no version", "overrides:\n  - version: example.com/module\n    action: deny\n    reason: x\n"},
+		{"invalid version", "overrides:\n  - version: example.com/module@latest\n    action: deny\n    reason: x\n"},
+		{"invalid action", "overrides:\n  - version: example.com/module@v1.0.0\n    action: block\n    reason: x\n"},
+		{"no reason", "overrides:\n  - version: example.com/module@v1.0.0\n    action: deny\n"},
+		{"invalid expiry", "overrides:\n  - version: example.com/module@v1.0.0\n    action: deny\n    reason: x\n    expires: soon\n"},
+		{"duplicate", "overrides:\n  - version: example.com/module@v1.0.0\n    action: deny\n    reason: x\n  - version: example.com/module@v1.0.0\n    action: allow\n    reason: y\n"},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := parseOverrides([]byte(tt.input)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestOverridesProxy(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	// v1.0.0 and v1.1.0 are old, v1.2.0 is new
+	times := map[string]time.Time{
+		"v1.0.0": time.Now().Add(-30 * 24 * time.Hour),
+		"v1.1.0": time.Now().Add(-20 * 24 * time.Hour),
+		"v1.2.0": time.Now().Add(-1 * time.Hour),
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			w.Write([]byte("v1.0.0\nv1.1.0\nv1.2.0\n"))
+		case strings.HasSuffix(r.URL.Path, "/@latest"):
+			json.NewEncoder(w).Encode(VersionInfo{Version: "v1.2.0", Time: times["v1.2.0"]})
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: times[version]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	newProxy := func(overrides string) *Proxy {
+		o, err := parseOverrides([]byte(overrides))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache, err := lru.New[string, *VersionInfo](100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Proxy{
+			upstream:        upstream.URL,
+			client:          &http.Client{Timeout: 30 * time.Second},
+			cache:           cache,
+			defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+			listConcurrency: 4,
+			listTimeout:     30 * time.Second,
+			overrides:       o,
+		}
+	}
+
+	for _, tt := range []struct {
+		desc       string
+		overrides  string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantList   string
+	}{{
+		desc:       "list",
+		overrides:  testOverrides,
+		path:       "/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.1.0\nv1.2.0\n",
+	}, {
+		desc:       "denied info",
+		overrides:  testOverrides,
+		path:       "/example.com/module/@v/v1.0.0.info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "allowed info",
+		overrides:  testOverrides,
+		path:       "/example.com/module/@v/v1.2.0.info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "denied download",
+		overrides:  testOverrides,
+		path:       "/example.com/module/@v/v1.0.0.zip",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "allowed download",
+		overrides:  testOverrides,
+		path:       "/example.com/module/@v/v1.2.0.mod",
+		wantStatus: http.StatusTemporaryRedirect,
+	}, {
+		desc:       "allowed latest",
+		overrides:  testOverrides,
+		path:       "/example.com/module/@latest",
+		wantStatus: http.StatusOK,
+		wantBody:   `"Version":"v1.2.0"`,
+	}, {
+		desc:       "latest skips denied version",
+		overrides:  "overrides:\n  - version: example.com/module@v1.1.0\n    action: deny\n    reason: x\n",
+		path:       "/example.com/module/@latest",
+		wantStatus: http.StatusOK,
+		wantBody:   `"Version":"v1.0.0"`,
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			proxy := newProxy(tt.overrides)
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body: got %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantList != "" && w.Body.String() != tt.wantList {
+				t.Errorf("list: got %q, want %q", w.Body.String(), tt.wantList)
+			}
+		})
+	}
+}
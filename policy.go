@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy maps module path patterns to cooldowns. It's loaded from a YAML
+// file like:
+//
+//	cooldowns:
+//	  github.com/ourorg/*: 0
+//	  golang.org/x/*: 3d
+//	  "*": 14d
+//
+// Patterns are matched like GOPRIVATE: a glob pattern matches a module path
+// if it matches a prefix of the path's elements, so golang.org/x/* matches
+// golang.org/x/net and golang.org/x/net/html. If several patterns match, the
+// most specific one wins.
+//
+// A policy can also have a CEL rule, which replaces the cooldown check with
+// an arbitrary expression (see rule):
+//
+//	rule: |
+//	  module.startsWith("github.com/ourorg/") || age >= cooldown
+type Policy struct {
+	rules []policyRule // most specific first
+	rule  *rule        // may be nil
+}
+
+type policyRule struct {
+	pattern  string
+	cooldown Cooldown
+}
+
+type policyFile struct {
+	Cooldowns map[string]string `yaml:"cooldowns"`
+	Rule      string            `yaml:"rule"`
+}
+
+func loadPolicy(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+	return parsePolicy(b)
+}
+
+func parsePolicy(b []byte) (*Policy, error) {
+	var f policyFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	var p Policy
+	for pattern, s := range f.Cooldowns {
+		if pattern == "" {
+			return nil, fmt.Errorf("empty module pattern")
+		}
+		c, err := parseCooldown(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cooldown for %q: %w", pattern, err)
+		}
+		if c.fixed < 0 {
+			return nil, fmt.Errorf("negative cooldown for %q", pattern)
+		}
+		p.rules = append(p.rules, policyRule{pattern: pattern, cooldown: c})
+	}
+
+	if f.Rule != "" {
+		r, err := compileRule(f.Rule)
+		if err != nil {
+			return nil, err
+		}
+		p.rule = r
+	}
+
+	// More path elements is more specific, then fewer wildcards, then
+	// longer patterns. Ties are broken by name so the order is stable.
+	slices.SortFunc(p.rules, func(a, b policyRule) int {
+		if c := strings.Count(b.pattern, "/") - strings.Count(a.pattern, "/"); c != 0 {
+			return c
+		}
+		if c := strings.Count(a.pattern, "*") - strings.Count(b.pattern, "*"); c != 0 {
+			return c
+		}
+		if c := len(b.pattern) - len(a.pattern); c != 0 {
+			return c
+		}
+		return strings.Compare(a.pattern, b.pattern)
+	})
+	return &p, nil
+}
+
+// Cooldown returns the cooldown for modulePath, which may be escaped as in
+// proxy URLs, and whether any pattern matched it.
+func (p *Policy) Cooldown(modulePath string) (Cooldown, bool) {
+	if p == nil {
+		return Cooldown{}, false
+	}
+	if unescaped, err := module.UnescapePath(modulePath); err == nil {
+		modulePath = unescaped
+	}
+	for _, r := range p.rules {
+		if module.MatchPrefixPatterns(r.pattern, modulePath) {
+			return r.cooldown, true
+		}
+	}
+	return Cooldown{}, false
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const testPolicy = `
+cooldowns:
+  github.com/ourorg/*: 0
+  github.com/ourorg/untrusted: 30d
+  golang.org/x/*: 3d
+  github.com/Azure/*: 1d
+  "*": 14d
+`
+
+func TestPolicyCooldown(t *testing.T) {
+	policy, err := parsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := 24 * time.Hour
+	for _, tt := range []struct {
+		module string
+		want   time.Duration
+	}{
+		{"github.com/ourorg/repo", 0},
+		{"github.com/ourorg/repo/v2", 0},
+		{"github.com/ourorg/untrusted", 30 * day},
+		{"github.com/ourorg/untrusted/sub", 30 * day},
+		{"golang.org/x/net", 3 * day},
+		{"golang.org/xyz", 14 * day},
+		{"github.com/!azure/azure-sdk-for-go", 1 * day},
+		{"example.com/module", 14 * day},
+	} {
+		got, ok := policy.Cooldown(tt.module)
+		if !ok {
+			t.Errorf("Cooldown(%q): no match", tt.module)
+			continue
+		}
+		if got != fixedCooldown(tt.want) {
+			t.Errorf("Cooldown(%q) = %v, want %v", tt.module, got, tt.want)
+		}
+	}
+
+	// Without a catch-all, unmatched modules aren't covered
+	policy, err = parsePolicy([]byte("cooldowns:\n  golang.org/x/*: 3d\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := policy.Cooldown("example.com/module"); ok {
+		t.Errorf("Cooldown(example.com/module) should not match")
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	for _, tt := range []struct {
+		desc  string
+		input string
+	}{
+		{"invalid yaml", "cooldowns: [\n"},
+		{"invalid duration", "cooldowns:\n  '*': soon\n"},
+		{"negative duration", "cooldowns:\n  '*': -1h\n"},
+		{"empty pattern", "cooldowns:\n  '': 1d\n"},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := parsePolicy([]byte(tt.input)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestPolicyProxy(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	// Every version is 5 days old
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, ".info") {
+			http.NotFound(w, r)
+			return
+		}
+		info := VersionInfo{
+			Version: "v1.0.0",
+			Time:    time.Now().Add(-5 * 24 * time.Hour),
+		}
+		json.NewEncoder(w).Encode(info)
+	}))
+	defer upstream.Close()
+
+	policy, err := parsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+	}
+	proxy.policy.Store(policy)
+
+	for _, tt := range []struct {
+		desc       string
+		path       string
+		wantStatus int
+	}{
+		{
+			desc:       "trusted org has no cooldown",
+			path:       "/github.com/ourorg/repo/@v/v1.0.0.info",
+			wantStatus: http.StatusOK,
+		},
+		{
+			desc:       "shorter cooldown for golang.org/x",
+			path:       "/golang.org/x/net/@v/v1.0.0.info",
+			wantStatus: http.StatusOK,
+		},
+		{
+			desc:       "catch-all cooldown",
+			path:       "/example.com/module/@v/v1.0.0.info",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			desc:       "URL prefix can't loosen the policy",
+			path:       "/1d/example.com/module/@v/v1.0.0.info",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			desc:       "URL prefix can tighten the policy",
+			path:       "/7d/golang.org/x/net/@v/v1.0.0.info",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			desc:       "policy applies to downloads",
+			path:       "/example.com/module/@v/v1.0.0.zip",
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// prereleasePolicy is how prereleases are treated: excluded entirely, or
+// held to a cooldown of their own. It's set by PRERELEASE_COOLDOWN or a
+// pre= path option, either of which is a cooldown or "never".
+type prereleasePolicy struct {
+	exclude  bool
+	cooldown Cooldown
+}
+
+func parsePrereleasePolicy(s string) (prereleasePolicy, error) {
+	if s == "never" {
+		return prereleasePolicy{exclude: true}, nil
+	}
+	c, err := parseCooldown(s)
+	if err != nil {
+		return prereleasePolicy{}, err
+	}
+	return prereleasePolicy{cooldown: c}, nil
+}
+
+// isPrerelease reports whether v is a prerelease, like v2.0.0-rc.1.
+// Pseudo-versions have a prerelease suffix too, but they aren't releases of
+// any kind, so they don't count.
+func isPrerelease(v string) bool {
+	return semver.Prerelease(v) != "" && !module.IsPseudoVersion(v)
+}
+
+// prereleaseCutoff returns the cutoff for prereleases, given the cutoff for
+// releases, and whether prereleases are excluded entirely. The configured
+// policy and the one from the URL both apply, and a prerelease cooldown
+// can only make the cutoff earlier.
+func (p *Proxy) prereleaseCutoff(now, cutoff time.Time, fromURL *prereleasePolicy) (time.Time, bool) {
+	exclude := false
+	for _, pre := range []*prereleasePolicy{p.prerelease, fromURL} {
+		if pre == nil {
+			continue
+		}
+		exclude = exclude || pre.exclude
+		if c := pre.cooldown.Cutoff(now, p.calendar); c.Before(cutoff) {
+			cutoff = c
+		}
+	}
+	return cutoff, exclude
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+func TestIsPrerelease(t *testing.T) {
+	for _, tt := range []struct {
+		version string
+		want    bool
+	}{
+		{"v1.0.0", false},
+		{"v2.0.0-rc.1", true},
+		{"v2.0.0-alpha", true},
+		{"v2.0.0-rc.1+incompatible", true},
+		{"v3.0.0+incompatible", false},
+		{"v0.0.0-20240101000000-abcdefabcdef", false},
+		{"v1.2.4-0.20240101000000-abcdefabcdef", false},
+	} {
+		if got := isPrerelease(tt.version); got != tt.want {
+			t.Errorf("isPrerelease(%q) = %t, want %t", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestPrereleasePolicy(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	day := 24 * time.Hour
+	times := map[string]time.Time{
+		"v1.0.0":      time.Now().Add(-50 * day),
+		"v1.1.0-rc.1": time.Now().Add(-30 * day),
+		"v1.1.0":      time.Now().Add(-20 * day),
+		"v1.2.0-rc.1": time.Now().Add(-10 * day),
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			w.Write([]byte("v1.0.0\nv1.1.0-rc.1\nv1.1.0\nv1.2.0-rc.1\n"))
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: times[version]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	for _, tt := range []struct {
+		desc       string
+		configured string
+		path       string
+		wantStatus int
+		wantList   string
+	}{{
+		desc:       "no prerelease policy",
+		path:       "/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0-rc.1\nv1.1.0\nv1.2.0-rc.1\n",
+	}, {
+		desc:       "excluded",
+		path:       "/pre=never/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0\n",
+	}, {
+		desc:       "longer cooldown",
+		path:       "/7d/pre=14d/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0-rc.1\nv1.1.0\n",
+	}, {
+		desc:       "shorter cooldown doesn't loosen the release cooldown",
+		path:       "/25d/pre=14d/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0-rc.1\n",
+	}, {
+		desc:       "configured",
+		configured: "60d",
+		path:       "/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0\n",
+	}, {
+		desc:       "URL can't loosen configured cooldown",
+		configured: "60d",
+		path:       "/pre=3d/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0\n",
+	}, {
+		desc:       "URL can't loosen configured exclusion",
+		configured: "never",
+		path:       "/pre=3d/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0\n",
+	}, {
+		desc:       "info",
+		path:       "/pre=never/example.com/module/@v/v1.1.0-rc.1.info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "download",
+		path:       "/pre=14d/example.com/module/@v/v1.2.0-rc.1.zip",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "invalid option",
+		path:       "/7d/pre=soon/example.com/module/@v/list",
+		wantStatus: http.StatusBadRequest,
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			cache, err := lru.New[string, *VersionInfo](100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proxy := &Proxy{
+				upstream:        upstream.URL,
+				client:          &http.Client{Timeout: 30 * time.Second},
+				cache:           cache,
+				defaultCooldown: fixedCooldown(7 * day),
+				listConcurrency: 4,
+				listTimeout:     30 * time.Second,
+			}
+			if tt.configured != "" {
+				pre, err := parsePrereleasePolicy(tt.configured)
+				if err != nil {
+					t.Fatal(err)
+				}
+				proxy.prerelease = &pre
+			}
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantList != "" && w.Body.String() != tt.wantList {
+				t.Errorf("list: got %q, want %q", w.Body.String(), tt.wantList)
+			}
+		})
+	}
+}
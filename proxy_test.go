@@ -9,6 +9,8 @@ import (
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -65,6 +67,12 @@ func TestProxy(t *testing.T) {
 			}
 			json.NewEncoder(w).Encode(info)
 
+		case "/example.com/module/@v/v1.3.0.info":
+			http.Error(w, "gone", http.StatusGone)
+
+		case "/example.com/module/@v/v1.4.0.info":
+			http.Error(w, "internal error", http.StatusInternalServerError)
+
 		case "/example.com/module/@v/v1.0.0.zip":
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("fake zip content"))
@@ -96,7 +104,7 @@ func TestProxy(t *testing.T) {
 		upstream:        upstream.URL,
 		client:          &http.Client{Timeout: 30 * time.Second},
 		cache:           cache,
-		defaultCooldown: 7 * 24 * time.Hour,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
 	}
 
 	for _, tt := range []struct {
@@ -124,6 +132,21 @@ func TestProxy(t *testing.T) {
 			path:       "/example.com/module/@v/v2.0.0.info",
 			wantStatus: http.StatusNotFound,
 		},
+		{
+			desc:       "unknown version info is not found",
+			path:       "/example.com/module/@v/v9.9.9.info",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			desc:       "gone version info is passed through",
+			path:       "/example.com/module/@v/v1.3.0.info",
+			wantStatus: http.StatusGone,
+		},
+		{
+			desc:       "upstream error is a bad gateway",
+			path:       "/example.com/module/@v/v1.4.0.info",
+			wantStatus: http.StatusBadGateway,
+		},
 		{
 			desc:       "latest returns older version when latest is too new",
 			path:       "/example.com/module/@latest",
@@ -166,8 +189,27 @@ func TestProxyRedirects(t *testing.T) {
 	ctx = clog.WithLogger(ctx, log)
 
 	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("upstream content"))
+		switch r.URL.Path {
+		case "/example.com/module/@v/v1.0.0.info":
+			// Old version (30 days ago)
+			info := VersionInfo{
+				Version: "v1.0.0",
+				Time:    time.Now().Add(-30 * 24 * time.Hour),
+			}
+			json.NewEncoder(w).Encode(info)
+
+		case "/example.com/module/@v/v2.0.0.info":
+			// Recent version (1 day ago) - should not be downloadable
+			info := VersionInfo{
+				Version: "v2.0.0",
+				Time:    time.Now().Add(-1 * 24 * time.Hour),
+			}
+			json.NewEncoder(w).Encode(info)
+
+		default:
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("upstream content"))
+		}
 	}))
 	defer upstream.Close()
 
@@ -180,20 +222,42 @@ func TestProxyRedirects(t *testing.T) {
 		upstream:        upstream.URL,
 		client:          &http.Client{Timeout: 30 * time.Second},
 		cache:           cache,
-		defaultCooldown: 7 * 24 * time.Hour,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
 	}
 
 	for _, tt := range []struct {
-		desc string
-		path string
+		desc         string
+		path         string
+		wantStatus   int
+		wantLocation string
 	}{
 		{
-			desc: "zip files are redirected",
-			path: "/example.com/module/@v/v1.0.0.zip",
+			desc:         "zip files are redirected",
+			path:         "/example.com/module/@v/v1.0.0.zip",
+			wantStatus:   http.StatusTemporaryRedirect,
+			wantLocation: "/example.com/module/@v/v1.0.0.zip",
+		},
+		{
+			desc:         "mod files are redirected",
+			path:         "/example.com/module/@v/v1.0.0.mod",
+			wantStatus:   http.StatusTemporaryRedirect,
+			wantLocation: "/example.com/module/@v/v1.0.0.mod",
+		},
+		{
+			desc:       "recent zip files are filtered",
+			path:       "/example.com/module/@v/v2.0.0.zip",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			desc:       "recent mod files are filtered",
+			path:       "/example.com/module/@v/v2.0.0.mod",
+			wantStatus: http.StatusNotFound,
 		},
 		{
-			desc: "mod files are redirected",
-			path: "/example.com/module/@v/v1.0.0.mod",
+			desc:         "shorter cooldown prefix allows recent zip files",
+			path:         "/12h/example.com/module/@v/v2.0.0.zip",
+			wantStatus:   http.StatusTemporaryRedirect,
+			wantLocation: "/example.com/module/@v/v2.0.0.zip",
 		},
 	} {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -203,12 +267,15 @@ func TestProxyRedirects(t *testing.T) {
 
 			proxy.ServeHTTP(w, req)
 
-			if w.Code != http.StatusTemporaryRedirect {
-				t.Errorf("status: got %d, want %d", w.Code, http.StatusTemporaryRedirect)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantLocation == "" {
+				return
 			}
 
 			location := w.Header().Get("Location")
-			expectedLocation := upstream.URL + tt.path
+			expectedLocation := upstream.URL + tt.wantLocation
 			if location != expectedLocation {
 				t.Errorf("Location header: got %q, want %q", location, expectedLocation)
 			}
@@ -283,7 +350,7 @@ func TestCooldownPeriods(t *testing.T) {
 				upstream:        upstream.URL,
 				client:          &http.Client{Timeout: 30 * time.Second},
 				cache:           cache,
-				defaultCooldown: time.Duration(tt.cooldownDays) * 24 * time.Hour,
+				defaultCooldown: fixedCooldown(time.Duration(tt.cooldownDays) * 24 * time.Hour),
 			}
 
 			req := httptest.NewRequest("GET", "/example.com/module/@v/v1.0.0.info", nil)
@@ -304,3 +371,353 @@ func TestCooldownPeriods(t *testing.T) {
 		})
 	}
 }
+
+func TestAbsoluteCutoff(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	times := map[string]time.Time{
+		"v1.0.0": time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+		"v1.1.0": time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		"v1.2.0": time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC),
+		"v1.3.0": time.Now().Add(-1 * time.Hour),
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			fmt.Fprint(w, "v1.0.0\nv1.1.0\nv1.2.0\nv1.3.0\n")
+		case strings.HasSuffix(r.URL.Path, "/@latest"):
+			json.NewEncoder(w).Encode(VersionInfo{Version: "v1.3.0", Time: times["v1.3.0"]})
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: times[version]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	for _, tt := range []struct {
+		desc       string
+		policy     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{{
+		desc:       "date",
+		path:       "/at/2025-06-01/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantBody:   "v1.0.0\nv1.1.0\n",
+	}, {
+		desc:       "timestamp",
+		path:       "/at/2025-05-31T23:59:59Z/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantBody:   "v1.0.0\n",
+	}, {
+		desc:       "timestamp with offset",
+		path:       "/at/2025-06-01T02:00:00+02:00/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantBody:   "v1.0.0\nv1.1.0\n",
+	}, {
+		desc:       "future cutoff",
+		path:       "/at/2099-01-01/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantBody:   "v1.0.0\nv1.1.0\nv1.2.0\nv1.3.0\n",
+	}, {
+		desc:       "latest",
+		path:       "/at/2025-06-20/example.com/module/@latest",
+		wantStatus: http.StatusOK,
+		wantBody:   `{"Version":"v1.2.0","Time":"2025-06-15T00:00:00Z"}` + "\n",
+	}, {
+		desc:       "download",
+		path:       "/at/2025-06-01/example.com/module/@v/v1.2.0.zip",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "policy can be stricter",
+		policy:     "cooldowns:\n  '*': 100y\n",
+		path:       "/at/2025-06-01/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantBody:   "",
+	}, {
+		desc:       "policy can't be looser",
+		policy:     "cooldowns:\n  '*': 0\n",
+		path:       "/at/2025-06-01/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantBody:   "v1.0.0\nv1.1.0\n",
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			cache, err := lru.New[string, *VersionInfo](100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proxy := &Proxy{
+				upstream:        upstream.URL,
+				client:          &http.Client{Timeout: 30 * time.Second},
+				cache:           cache,
+				defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+				listConcurrency: 4,
+				listTimeout:     30 * time.Second,
+			}
+			if tt.policy != "" {
+				policy, err := parsePolicy([]byte(tt.policy))
+				if err != nil {
+					t.Fatal(err)
+				}
+				proxy.policy.Store(policy)
+			}
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.String() != tt.wantBody {
+				t.Errorf("body: got %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestListConcurrency(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	const numVersions = 50
+	const limit = 4
+
+	var inFlight, maxInFlight atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/example.com/module/@v/list" {
+			for i := range numVersions {
+				fmt.Fprintf(w, "v1.%d.0\n", i)
+			}
+			return
+		}
+
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		version := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/example.com/module/@v/"), ".info")
+		info := VersionInfo{
+			Version: version,
+			Time:    time.Now().Add(-30 * 24 * time.Hour),
+		}
+		json.NewEncoder(w).Encode(info)
+	}))
+	defer upstream.Close()
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+		listConcurrency: limit,
+	}
+
+	req := httptest.NewRequest("GET", "/example.com/module/@v/list", nil)
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	proxy.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want %d", w.Code, http.StatusOK)
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != numVersions {
+		t.Fatalf("expected %d lines, got %d", numVersions, len(lines))
+	}
+	for i, line := range lines {
+		if want := fmt.Sprintf("v1.%d.0", i); line != want {
+			t.Errorf("line %d: got %q, want %q", i, line, want)
+		}
+	}
+
+	if got := maxInFlight.Load(); got > limit || got < 2 {
+		t.Errorf("max concurrent fetches: got %d, want between 2 and %d", got, limit)
+	}
+}
+
+func TestListTimeout(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	done := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/module/@v/list":
+			fmt.Fprintln(w, "v1.0.0")
+			fmt.Fprintln(w, "v1.1.0")
+
+		case "/example.com/module/@v/v1.0.0.info":
+			info := VersionInfo{
+				Version: "v1.0.0",
+				Time:    time.Now().Add(-30 * 24 * time.Hour),
+			}
+			json.NewEncoder(w).Encode(info)
+
+		case "/example.com/module/@v/v1.1.0.info":
+			// Hang until the test is over
+			select {
+			case <-r.Context().Done():
+			case <-done:
+			}
+		}
+	}))
+	defer upstream.Close()
+	defer close(done)
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+		listConcurrency: 2,
+		listTimeout:     100 * time.Millisecond,
+	}
+
+	req := httptest.NewRequest("GET", "/example.com/module/@v/list", nil)
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	proxy.ServeHTTP(w, req)
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("list took %v, expected the deadline to cut it short", elapsed)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Body.String(), "v1.0.0\n"; got != want {
+		t.Errorf("body: got %q, want %q", got, want)
+	}
+}
+
+func TestCoalescing(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	var mu sync.Mutex
+	hits := map[string]int{}
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+
+		// Hold every response until all clients have made their requests
+		<-release
+
+		switch r.URL.Path {
+		case "/example.com/module/@v/list":
+			fmt.Fprintln(w, "v1.0.0")
+
+		case "/example.com/module/@latest", "/example.com/module/@v/v1.0.0.info":
+			info := VersionInfo{
+				Version: "v1.0.0",
+				Time:    time.Now().Add(-30 * 24 * time.Hour),
+			}
+			json.NewEncoder(w).Encode(info)
+
+		case "/sumdb/sum.golang.org/tile/8/0/001":
+			w.Write([]byte("tile data"))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tileCache, err := lru.New[string, []byte](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		tileCache:       tileCache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+	}
+
+	const clients = 20
+	paths := []string{
+		"/example.com/module/@v/v1.0.0.info",
+		"/example.com/module/@latest",
+		"/sumdb/sum.golang.org/tile/8/0/001",
+	}
+
+	var wg sync.WaitGroup
+	codes := make(chan int, clients*len(paths))
+	for range clients {
+		for _, path := range paths {
+			wg.Go(func() {
+				req := httptest.NewRequest("GET", path, nil)
+				req = req.WithContext(ctx)
+				w := httptest.NewRecorder()
+				proxy.ServeHTTP(w, req)
+				codes <- w.Code
+			})
+		}
+	}
+
+	// Give every client a chance to join the in-flight fetches
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(codes)
+
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("status: got %d, want %d", code, http.StatusOK)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for path, n := range hits {
+		if n != 1 {
+			t.Errorf("upstream fetches of %s: got %d, want 1", path, n)
+		}
+	}
+}
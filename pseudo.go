@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"golang.org/x/mod/module"
+)
+
+// pseudoVersionPolicy is how pseudo-versions are treated. It's set by
+// PSEUDO_VERSIONS or a pseudo= path option, either of which is a
+// comma-separated list of:
+//
+//	never       pseudo-versions are never served
+//	base        pseudo-versions are only served if the tagged version
+//	            they're based on is eligible
+//	<cooldown>  pseudo-versions are held to this cooldown too
+//
+// Pseudo-versions are what go get module@main resolves to, so they haven't
+// been through whatever review a tag gets.
+type pseudoVersionPolicy struct {
+	forbid      bool
+	requireBase bool
+	cooldown    *Cooldown
+}
+
+func parsePseudoVersionPolicy(s string) (pseudoVersionPolicy, error) {
+	var pv pseudoVersionPolicy
+	for _, part := range strings.Split(s, ",") {
+		switch part {
+		case "never":
+			pv.forbid = true
+		case "base":
+			pv.requireBase = true
+		default:
+			if pv.cooldown != nil {
+				return pseudoVersionPolicy{}, fmt.Errorf("more than one cooldown in %q", s)
+			}
+			c, err := parseCooldown(part)
+			if err != nil {
+				return pseudoVersionPolicy{}, err
+			}
+			pv.cooldown = &c
+		}
+	}
+	return pv, nil
+}
+
+// pseudoVersionRules returns how pseudo-versions are checked: whether
+// they're forbidden, whether their base version must be eligible, and a
+// cutoff they're held to as well as the usual one, which is zero if there's
+// none. The configured policy and the one from the URL both apply.
+func (p *Proxy) pseudoVersionRules(now time.Time, fromURL *pseudoVersionPolicy) (forbid, requireBase bool, cutoff time.Time) {
+	for _, pv := range []*pseudoVersionPolicy{p.pseudoVersions, fromURL} {
+		if pv == nil {
+			continue
+		}
+		forbid = forbid || pv.forbid
+		requireBase = requireBase || pv.requireBase
+		if pv.cooldown != nil {
+			if c := pv.cooldown.Cutoff(now, p.calendar); cutoff.IsZero() || c.Before(cutoff) {
+				cutoff = c
+			}
+		}
+	}
+	return forbid, requireBase, cutoff
+}
+
+// pseudoVersionAllowed reports whether the pseudo-version version, first
+// seen at t, passes the pseudo-version policy.
+func (p *Proxy) pseudoVersionAllowed(ctx context.Context, vc *versionCheck, version string, t time.Time) bool {
+	log := clog.FromContext(ctx)
+
+	if vc.forbidPseudo {
+		log.InfoContext(ctx, "pseudo-versions forbidden", "version", version)
+		return false
+	}
+	if !vc.pseudoCutoff.IsZero() && t.After(vc.pseudoCutoff) {
+		log.InfoContext(ctx, "pseudo-version too new", "version", version, "time", t, "cutoff", vc.pseudoCutoff)
+		return false
+	}
+	if !vc.requireBase {
+		return true
+	}
+
+	// Versions like v0.0.0-20240101120000-abcdef123456 have no base
+	base, err := module.PseudoVersionBase(version)
+	if err != nil || base == "" {
+		log.InfoContext(ctx, "pseudo-version has no base version", "version", version)
+		return false
+	}
+	info, err := p.fetchVersionInfo(ctx, vc.modulePath, base)
+	if err != nil {
+		log.WarnContext(ctx, "failed to fetch base version info", "version", version, "base", base, "error", err)
+		return false
+	}
+	previous, err := p.previousEligible(ctx, vc, vc.modulePath, base)
+	if err != nil {
+		log.WarnContext(ctx, "failed to find previous eligible version", "version", base, "error", err)
+		return false
+	}
+
+	// Don't report the base version as a security fix, since it isn't
+	// being served.
+	check := *vc
+	check.header = http.Header{}
+	if !p.eligible(ctx, &check, previous, base, info) {
+		log.InfoContext(ctx, "pseudo-version's base version not eligible", "version", version, "base", base)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/mod/module"
+)
+
+func TestParsePseudoVersionPolicy(t *testing.T) {
+	thirty := fixedCooldown(30 * 24 * time.Hour)
+	for _, tt := range []struct {
+		input   string
+		want    pseudoVersionPolicy
+		wantErr bool
+	}{
+		{input: "never", want: pseudoVersionPolicy{forbid: true}},
+		{input: "base", want: pseudoVersionPolicy{requireBase: true}},
+		{input: "30d", want: pseudoVersionPolicy{cooldown: &thirty}},
+		{input: "base,30d", want: pseudoVersionPolicy{requireBase: true, cooldown: &thirty}},
+		{input: "soon", wantErr: true},
+		{input: "30d,60d", wantErr: true},
+	} {
+		got, err := parsePseudoVersionPolicy(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parsePseudoVersionPolicy(%q): expected error", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePseudoVersionPolicy(%q): %v", tt.input, err)
+			continue
+		}
+		if got.forbid != tt.want.forbid || got.requireBase != tt.want.requireBase ||
+			(got.cooldown == nil) != (tt.want.cooldown == nil) || got.cooldown != nil && *got.cooldown != *tt.want.cooldown {
+			t.Errorf("parsePseudoVersionPolicy(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPseudoVersionPolicy(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	day := 24 * time.Hour
+	times := map[string]time.Time{
+		"v1.0.0": time.Now().Add(-100 * day),
+		"v1.1.0": time.Now().Add(-2 * day),
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			w.Write([]byte("v1.0.0\nv1.1.0\n"))
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			if t, err := module.PseudoVersionTime(version); err == nil {
+				json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: t})
+				return
+			}
+			if _, ok := times[version]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: times[version]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	// Based on an eligible version, on one that's too new, and on nothing
+	basedOnOld := module.PseudoVersion("v1", "v1.0.0", time.Now().Add(-20*day), "abcdefabcdef")
+	basedOnNew := module.PseudoVersion("v1", "v1.1.0", time.Now().Add(-10*day), "abcdefabcdef")
+	untagged := module.PseudoVersion("v0", "", time.Now().Add(-20*day), "abcdefabcdef")
+
+	for _, tt := range []struct {
+		desc       string
+		configured string
+		path       string
+		wantStatus int
+	}{{
+		desc:       "no pseudo-version policy",
+		path:       "/example.com/module/@v/" + basedOnNew + ".info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "forbidden",
+		path:       "/pseudo=never/example.com/module/@v/" + basedOnOld + ".info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "forbidden download",
+		path:       "/pseudo=never/example.com/module/@v/" + basedOnOld + ".zip",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "tagged versions are unaffected",
+		path:       "/pseudo=never/example.com/module/@v/v1.0.0.info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "longer cooldown",
+		path:       "/pseudo=30d/example.com/module/@v/" + basedOnOld + ".info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "past longer cooldown",
+		path:       "/pseudo=14d/example.com/module/@v/" + basedOnOld + ".info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "base version eligible",
+		path:       "/pseudo=base/example.com/module/@v/" + basedOnOld + ".info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "base version too new",
+		path:       "/pseudo=base/example.com/module/@v/" + basedOnNew + ".info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "no base version",
+		path:       "/pseudo=base/example.com/module/@v/" + untagged + ".mod",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "configured",
+		configured: "base",
+		path:       "/example.com/module/@v/" + basedOnNew + ".info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "configured and URL policies both apply",
+		configured: "base",
+		path:       "/pseudo=30d/example.com/module/@v/" + basedOnOld + ".info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "invalid option",
+		path:       "/pseudo=soon/example.com/module/@v/" + basedOnOld + ".info",
+		wantStatus: http.StatusBadRequest,
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			cache, err := lru.New[string, *VersionInfo](100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proxy := &Proxy{
+				upstream:        upstream.URL,
+				client:          &http.Client{Timeout: 30 * time.Second},
+				cache:           cache,
+				defaultCooldown: fixedCooldown(7 * day),
+				listConcurrency: 4,
+				listTimeout:     30 * time.Second,
+			}
+			if tt.configured != "" {
+				pv, err := parsePseudoVersionPolicy(tt.configured)
+				if err != nil {
+					t.Fatal(err)
+				}
+				proxy.pseudoVersions = &pv
+			}
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
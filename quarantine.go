@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"golang.org/x/mod/module"
+)
+
+// quarantined reports whether version, first seen at t, belongs to a module
+// too new to serve at all: one whose earliest known version is more recent
+// than NEW_MODULE_QUARANTINE allows. Typosquats are usually brand-new
+// modules, so this protects go get of unfamiliar paths in a way per-version
+// cooldowns can't, since every version of such a module is new.
+//
+// Errors fail closed, treating the module as new.
+func (p *Proxy) quarantined(ctx context.Context, vc *versionCheck, version string, t time.Time) bool {
+	if p.quarantine == nil {
+		return false
+	}
+	log := clog.FromContext(ctx)
+
+	modulePath := vc.modulePath
+	if unescaped, err := module.UnescapePath(modulePath); err == nil {
+		modulePath = unescaped
+	}
+	if hasModulePrefix(modulePath, p.quarantineExempt) {
+		return false
+	}
+
+	// An old enough version is proof enough, without checking the others
+	cutoff := p.quarantine.Cutoff(vc.now, p.calendar)
+	if !t.After(cutoff) {
+		return false
+	}
+
+	earliest, err := p.earliestVersionTime(ctx, vc.modulePath)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to determine module age", "error", err)
+		return true
+	}
+	if !earliest.IsZero() && !earliest.After(cutoff) {
+		return false
+	}
+	log.InfoContext(ctx, "module quarantined as new", "version", version, "earliest", earliest, "cutoff", cutoff)
+	return true
+}
+
+// earliestVersionTime returns the time of the earliest version of
+// modulePath in its upstream version list, or the zero time if there are no
+// tagged versions. It's cached per module once every version's info has been
+// fetched, since the earliest version of a module doesn't change.
+func (p *Proxy) earliestVersionTime(ctx context.Context, modulePath string) (time.Time, error) {
+	if p.moduleAgeCache != nil {
+		if earliest, ok := p.moduleAgeCache.Get(modulePath); ok {
+			return earliest, nil
+		}
+	}
+
+	resp, err := p.fetchList(ctx, modulePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var versions []string
+	switch resp.status {
+	case http.StatusOK:
+		versions = strings.Fields(string(resp.body))
+	case http.StatusNotFound, http.StatusGone:
+		// No tagged versions
+	default:
+		return time.Time{}, fmt.Errorf("upstream returned %d for version list", resp.status)
+	}
+
+	var earliest time.Time
+	complete := true
+	for i, info := range p.fetchVersionInfos(ctx, modulePath, versions) {
+		if info == nil {
+			complete = false
+			continue
+		}
+		t, err := p.firstSeenTime(modulePath, versions[i], info)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if earliest.IsZero() || t.Before(earliest) {
+			earliest = t
+		}
+	}
+
+	// A version whose info couldn't be fetched might be the earliest, and a
+	// module with no tagged versions yet will get some.
+	if complete && !earliest.IsZero() && p.moduleAgeCache != nil {
+		p.moduleAgeCache.Add(modulePath, earliest)
+	}
+	return earliest, nil
+}
+
+// firstSeenTime returns the later of a version's upstream time and when the
+// module index first reported it, so a backdated first tag doesn't clear the
+// quarantine when FIRST_SEEN_DB is set. Unlike versionTime it only reads the
+// store: checking a module's age mustn't start the clock on every version
+// it has, or every module new to the proxy would look brand new.
+func (p *Proxy) firstSeenTime(modulePath, version string, info *VersionInfo) (time.Time, error) {
+	if p.firstSeen == nil {
+		return info.Time, nil
+	}
+	if unescaped, err := module.UnescapePath(modulePath); err == nil {
+		modulePath = unescaped
+	}
+	if unescaped, err := module.UnescapeVersion(version); err == nil {
+		version = unescaped
+	}
+	seen, ok, err := p.firstSeen.Get(modulePath, version)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading first-seen time of %s@%s: %w", modulePath, version, err)
+	}
+	if ok && seen.After(info.Time) {
+		return seen, nil
+	}
+	return info.Time, nil
+}
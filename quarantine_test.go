@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/mod/module"
+)
+
+func TestNewModuleQuarantine(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	day := 24 * time.Hour
+	modules := map[string]map[string]time.Time{
+		"example.com/old": {
+			"v1.0.0": time.Now().Add(-100 * day),
+			"v1.1.0": time.Now().Add(-2 * day),
+		},
+		"example.com/new": {
+			"v1.0.0": time.Now().Add(-10 * day),
+			"v1.0.1": time.Now().Add(-8 * day),
+		},
+		"example.com/ourorg/new": {
+			"v1.0.0": time.Now().Add(-10 * day),
+		},
+		"example.com/untagged": {},
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		modulePath, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/@")
+		if modulePath == "example.com/broken" {
+			http.Error(w, "oops", http.StatusInternalServerError)
+			return
+		}
+		versions, ok := modules[modulePath]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case rest == "v/list":
+			for _, v := range []string{"v1.0.0", "v1.0.1", "v1.1.0"} {
+				if _, ok := versions[v]; ok {
+					w.Write([]byte(v + "\n"))
+				}
+			}
+		case rest == "latest":
+			json.NewEncoder(w).Encode(VersionInfo{Version: "v1.0.1", Time: versions["v1.0.1"]})
+		case strings.HasSuffix(rest, ".info"):
+			version := strings.TrimSuffix(strings.TrimPrefix(rest, "v/"), ".info")
+			if t, err := module.PseudoVersionTime(version); err == nil {
+				json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: t})
+				return
+			}
+			if _, ok := versions[version]; !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: versions[version]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moduleAgeCache, err := lru.New[string, time.Time](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides, err := parseOverrides([]byte(`
+overrides:
+  - version: example.com/new@v1.0.1
+    action: allow
+    reason: reviewed
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	quarantine := fixedCooldown(30 * day)
+	proxy := &Proxy{
+		upstream:         upstream.URL,
+		client:           &http.Client{Timeout: 30 * time.Second},
+		cache:            cache,
+		moduleAgeCache:   moduleAgeCache,
+		defaultCooldown:  fixedCooldown(day),
+		quarantine:       &quarantine,
+		quarantineExempt: []string{"example.com/ourorg"},
+		overrides:        overrides,
+		listConcurrency:  4,
+		listTimeout:      30 * time.Second,
+	}
+
+	oldPseudo := module.PseudoVersion("v0", "", time.Now().Add(-60*day), "abcdefabcdef")
+	newPseudo := module.PseudoVersion("v0", "", time.Now().Add(-5*day), "abcdefabcdef")
+
+	for _, tt := range []struct {
+		desc       string
+		path       string
+		wantStatus int
+		wantList   string
+		wantLatest string
+	}{{
+		desc:       "old module",
+		path:       "/example.com/old/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0\n",
+	}, {
+		desc:       "new module list",
+		path:       "/example.com/new/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.1\n",
+	}, {
+		desc:       "new module info",
+		path:       "/example.com/new/@v/v1.0.0.info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "new module download",
+		path:       "/example.com/new/@v/v1.0.0.zip",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "override",
+		path:       "/example.com/new/@v/v1.0.1.info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "new module latest",
+		path:       "/example.com/new/@latest",
+		wantStatus: http.StatusOK,
+		wantLatest: "v1.0.1",
+	}, {
+		desc:       "exempt module",
+		path:       "/example.com/ourorg/new/@v/v1.0.0.info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "untagged module with an old pseudo-version",
+		path:       "/example.com/untagged/@v/" + oldPseudo + ".info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "untagged module with only new pseudo-versions",
+		path:       "/example.com/untagged/@v/" + newPseudo + ".info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "fails closed",
+		path:       "/example.com/broken/@v/" + newPseudo + ".info",
+		wantStatus: http.StatusNotFound,
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if strings.HasSuffix(tt.path, "/list") && w.Body.String() != tt.wantList {
+				t.Errorf("list: got %q, want %q", w.Body.String(), tt.wantList)
+			}
+			if tt.wantLatest != "" {
+				var info VersionInfo
+				if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+					t.Fatal(err)
+				}
+				if info.Version != tt.wantLatest {
+					t.Errorf("latest: got %s, want %s", info.Version, tt.wantLatest)
+				}
+			}
+		})
+	}
+
+	// The earliest version is cached per module, unless it couldn't be found
+	for _, modulePath := range []string{"example.com/old", "example.com/new"} {
+		if earliest, ok := moduleAgeCache.Get(modulePath); !ok || !earliest.Equal(modules[modulePath]["v1.0.0"]) {
+			t.Errorf("%s: cached earliest = %v, %t", modulePath, earliest, ok)
+		}
+	}
+	for _, modulePath := range []string{"example.com/broken", "example.com/untagged"} {
+		if _, ok := moduleAgeCache.Get(modulePath); ok {
+			t.Errorf("%s: earliest shouldn't be cached", modulePath)
+		}
+	}
+
+	// Once an untagged module has an old enough tag, it's out of quarantine
+	modules["example.com/untagged"] = map[string]time.Time{
+		"v1.0.0": time.Now().Add(-60 * day),
+	}
+	req := httptest.NewRequest("GET", "/example.com/untagged/@v/"+newPseudo+".info", nil)
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("newly tagged module: got %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestNewModuleQuarantineFirstSeen(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	// Both tags are old, or backdated
+	day := 24 * time.Hour
+	times := map[string]time.Time{
+		"v1.0.0": time.Now().Add(-400 * day),
+		"v1.0.1": time.Now().Add(-300 * day),
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			w.Write([]byte("v1.0.0\nv1.0.1\n"))
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: times[version]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		name       string
+		seen       map[string]time.Duration
+		wantStatus int
+	}{
+		{
+			name:       "backdated tags first seen recently are quarantined",
+			seen:       map[string]time.Duration{"v1.0.0": 5 * day, "v1.0.1": 2 * day},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			// Only the index's sightings count, not the proxy's own
+			name:       "old module new to the store is allowed",
+			seen:       map[string]time.Duration{"v1.0.1": 2 * day},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			firstSeen, err := openFirstSeenStore(filepath.Join(t.TempDir(), "first-seen.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer firstSeen.Close()
+			for version, age := range tt.seen {
+				if _, err := firstSeen.Record("example.com/module", version, time.Now().Add(-age)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cache, err := lru.New[string, *VersionInfo](100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			quarantine := fixedCooldown(30 * day)
+			proxy := &Proxy{
+				upstream:        upstream.URL,
+				client:          &http.Client{Timeout: 30 * time.Second},
+				cache:           cache,
+				defaultCooldown: fixedCooldown(day),
+				quarantine:      &quarantine,
+				firstSeen:       firstSeen,
+				listConcurrency: 4,
+				listTimeout:     30 * time.Second,
+			}
+
+			req := httptest.NewRequest("GET", "/example.com/module/@v/v1.0.1.info", nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if _, ok := tt.seen["v1.0.0"]; !ok {
+				if _, ok, err := firstSeen.Get("example.com/module", "v1.0.0"); err != nil || ok {
+					t.Errorf("v1.0.0 recorded as seen by the module age check")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+func TestRedisCache(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+
+	client, err := newRedisClient(ctx, mr.Addr(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	c := &redisCache{client: client, prefix: "test:", ttl: time.Minute}
+	if _, ok, err := c.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("Get before Set: got ok=%t, err=%v", ok, err)
+	}
+	if err := c.Set(ctx, "key", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := c.Get(ctx, "key"); err != nil || !ok || string(got) != "value" {
+		t.Errorf("Get: got %q, %t, %v, want %q", got, ok, err, "value")
+	}
+	if !mr.Exists("test:key") {
+		t.Errorf("key should be stored with its prefix")
+	}
+
+	mr.FastForward(2 * time.Minute)
+	if _, ok, err := c.Get(ctx, "key"); err != nil || ok {
+		t.Errorf("Get after TTL: got ok=%t, err=%v", ok, err)
+	}
+}
+
+func TestRedisSharedBetweenReplicas(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	var mu sync.Mutex
+	hits := map[string]int{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/example.com/module/@v/list":
+			fmt.Fprintln(w, "v1.0.0")
+			fmt.Fprintln(w, "v1.1.0")
+
+		case "/example.com/module/@v/v1.0.0.info":
+			info := VersionInfo{
+				Version: "v1.0.0",
+				Time:    time.Now().Add(-30 * 24 * time.Hour),
+			}
+			json.NewEncoder(w).Encode(info)
+
+		case "/example.com/module/@v/v1.1.0.info":
+			info := VersionInfo{
+				Version: "v1.1.0",
+				Time:    time.Now().Add(-1 * 24 * time.Hour),
+			}
+			json.NewEncoder(w).Encode(info)
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	mr := miniredis.RunT(t)
+	client, err := newRedisClient(ctx, mr.Addr(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	newReplica := func() *Proxy {
+		cache, err := lru.New[string, *VersionInfo](100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Proxy{
+			upstream:        upstream.URL,
+			client:          &http.Client{Timeout: 30 * time.Second},
+			cache:           cache,
+			infoStore:       &redisCache{client: client, prefix: "info:"},
+			listCache:       expirable.NewLRU[string, *upstreamResponse](100, nil, time.Minute),
+			listStore:       &redisCache{client: client, prefix: "list:", ttl: time.Minute},
+			defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+		}
+	}
+
+	for i, proxy := range []*Proxy{newReplica(), newReplica(), newReplica()} {
+		req := httptest.NewRequest("GET", "/example.com/module/@v/list", nil)
+		req = req.WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		proxy.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("replica %d status: got %d, want %d", i, w.Code, http.StatusOK)
+		}
+		if got, want := w.Body.String(), "v1.0.0\n"; got != want {
+			t.Errorf("replica %d body: got %q, want %q", i, got, want)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for path, n := range hits {
+		// Retractions are cached per replica
+		if strings.HasSuffix(path, ".mod") {
+			continue
+		}
+		if n != 1 {
+			t.Errorf("upstream fetches of %s: got %d, want 1", path, n)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/fsnotify/fsnotify"
+)
+
+// policyDebounce is how long watchPolicy waits for changes to the policy
+// file to settle before reloading it, since writers commonly truncate the
+// file and then write it.
+const policyDebounce = 100 * time.Millisecond
+
+// reloadPolicy loads and validates the policy at path, and swaps it in if
+// it's valid. If it isn't, the current policy stays in place.
+//
+// A policy with no cooldowns and no rule is rejected: it's almost certainly
+// a file caught mid-write, and swapping it in would silently fall back to
+// DEFAULT_COOLDOWN for every module.
+func (p *Proxy) reloadPolicy(ctx context.Context, path string) error {
+	policy, err := loadPolicy(path)
+	if err != nil {
+		return err
+	}
+	if len(policy.rules) == 0 && policy.rule == nil {
+		return fmt.Errorf("policy %s has no cooldowns or rule", path)
+	}
+	p.policy.Store(policy)
+	clog.FromContext(ctx).InfoContext(ctx, "loaded policy", "path", path, "rules", len(policy.rules))
+	return nil
+}
+
+// watchPolicy reloads the policy at path whenever it changes or the process
+// receives SIGHUP, until ctx is done. Failed reloads are logged, and leave
+// the current policy in place.
+func (p *Proxy) watchPolicy(ctx context.Context, path string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// Watch the directory rather than the file, so that editors and
+	// Kubernetes ConfigMaps that replace the file by renaming are noticed.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		log := clog.FromContext(ctx)
+		defer watcher.Close()
+		defer signal.Stop(hup)
+
+		reload := func(reason string) {
+			if err := p.reloadPolicy(ctx, path); err != nil {
+				log.ErrorContext(ctx, "failed to reload policy, keeping current policy", "reason", reason, "error", err)
+			}
+		}
+
+		// settled fires once the policy file hasn't changed for policyDebounce
+		var settled <-chan time.Time
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				reload("signal")
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+					continue
+				}
+				// ConfigMaps update the file by swapping a "..data" symlink
+				base := filepath.Base(ev.Name)
+				if base != filepath.Base(path) && !strings.HasPrefix(base, "..") {
+					continue
+				}
+				settled = time.After(policyDebounce)
+			case <-settled:
+				settled = nil
+				reload("file changed")
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.ErrorContext(ctx, "policy watcher error", "error", err)
+			}
+		}
+	}()
+	return nil
+}
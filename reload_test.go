@@ -0,0 +1,139 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+)
+
+func TestWatchPolicy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	// The watched path is a symlink to a file in another directory, so that
+	// writes to the target aren't seen by the watcher and only SIGHUP
+	// reloads them.
+	target := filepath.Join(t.TempDir(), "policy.yaml")
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &Proxy{defaultCooldown: fixedCooldown(7 * 24 * time.Hour)}
+
+	// waitFor polls until the policy's cooldown for example.com/module is want.
+	waitFor := func(want time.Duration) {
+		t.Helper()
+		var got Cooldown
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+			if got, _ = p.policy.Load().Cooldown("example.com/module"); got == fixedCooldown(want) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("cooldown: got %v, want %v", got, want)
+	}
+
+	write(path, "cooldowns:\n  '*': 1d\n")
+	if err := p.reloadPolicy(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.watchPolicy(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(24 * time.Hour)
+
+	// A valid change is picked up
+	write(path, "cooldowns:\n  '*': 2d\n")
+	waitFor(48 * time.Hour)
+
+	// Invalid and empty changes are ignored
+	write(path, "cooldowns:\n  '*': whenever\n")
+	time.Sleep(3 * policyDebounce)
+	waitFor(48 * time.Hour)
+	write(path, "")
+	time.Sleep(3 * policyDebounce)
+	waitFor(48 * time.Hour)
+
+	// Swapping in a symlink is a change too
+	write(target, "cooldowns:\n  '*': 3d\n")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(72 * time.Hour)
+
+	// Changes the watcher can't see are picked up on SIGHUP
+	write(target, "cooldowns:\n  '*': 4d\n")
+	time.Sleep(3 * policyDebounce)
+	if got, _ := p.policy.Load().Cooldown("example.com/module"); got != fixedCooldown(72*time.Hour) {
+		t.Fatalf("cooldown changed without SIGHUP: got %v", got)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(96 * time.Hour)
+}
+
+func TestReloadPolicyKeepsOldPolicyOnError(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+
+	p := &Proxy{defaultCooldown: fixedCooldown(7 * 24 * time.Hour)}
+	if err := os.WriteFile(path, []byte("cooldowns:\n  '*': 1d\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.reloadPolicy(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+	old := p.policy.Load()
+
+	if err := os.WriteFile(path, []byte("cooldowns: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.reloadPolicy(ctx, path); err == nil {
+		t.Errorf("expected error reloading invalid policy")
+	}
+	if p.policy.Load() != old {
+		t.Errorf("policy was replaced by an invalid one")
+	}
+
+	for _, content := range []string{"", "# nothing yet\n", "cooldowns: {}\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.reloadPolicy(ctx, path); err == nil {
+			t.Errorf("expected error reloading empty policy %q", content)
+		}
+		if p.policy.Load() != old {
+			t.Errorf("policy was replaced by empty policy %q", content)
+		}
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.reloadPolicy(ctx, path); err == nil {
+		t.Errorf("expected error reloading missing policy")
+	}
+	if p.policy.Load() != old {
+		t.Errorf("policy was replaced after the file was removed")
+	}
+}
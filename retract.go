@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/chainguard-dev/clog"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// retractions returns the version intervals retracted by the go.mod file of
+// modulePath@version. Like the go command, the proxy honors the retractions
+// of the latest version, which here means the latest eligible version, so
+// that a version still in its cooldown can't retract older ones.
+//
+// Errors are logged and treated as no retractions, since every version
+// returned without them has passed its cooldown anyway.
+func (p *Proxy) retractions(ctx context.Context, modulePath, version string) []modfile.VersionInterval {
+	log := clog.FromContext(ctx)
+	key := modulePath + "@" + version
+
+	if p.retractCache != nil {
+		if retracted, ok := p.retractCache.Get(key); ok {
+			return retracted
+		}
+	}
+
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		log.WarnContext(ctx, "invalid version", "version", version, "error", err)
+		return nil
+	}
+	resp, err := p.fetchUpstream(ctx, fmt.Sprintf("/%s/@v/%s.mod", modulePath, escaped))
+	if err != nil {
+		log.WarnContext(ctx, "failed to fetch go.mod for retractions", "version", version, "error", err)
+		return nil
+	}
+	if resp.status != http.StatusOK {
+		log.WarnContext(ctx, "upstream returned non-200 for go.mod", "version", version, "status", resp.status)
+		return nil
+	}
+	f, err := modfile.ParseLax(key+"/go.mod", resp.body, nil)
+	if err != nil {
+		log.WarnContext(ctx, "failed to parse go.mod for retractions", "version", version, "error", err)
+		return nil
+	}
+
+	var retracted []modfile.VersionInterval
+	for _, r := range f.Retract {
+		retracted = append(retracted, r.VersionInterval)
+	}
+	if p.retractCache != nil {
+		p.retractCache.Add(key, retracted)
+	}
+	if len(retracted) > 0 {
+		log.DebugContext(ctx, "loaded retractions", "version", version, "retractions", len(retracted))
+	}
+	return retracted
+}
+
+// isRetracted reports whether version is in any of the intervals.
+func isRetracted(retracted []modfile.VersionInterval, version string) bool {
+	for _, r := range retracted {
+		if semver.Compare(r.Low, version) <= 0 && semver.Compare(version, r.High) <= 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/mod/modfile"
+)
+
+func TestIsRetracted(t *testing.T) {
+	retracted := []modfile.VersionInterval{
+		{Low: "v1.1.0", High: "v1.1.0"},
+		{Low: "v1.3.0", High: "v1.4.2"},
+	}
+	for _, tt := range []struct {
+		version string
+		want    bool
+	}{
+		{"v1.0.0", false},
+		{"v1.1.0", true},
+		{"v1.1.1", false},
+		{"v1.3.0", true},
+		{"v1.4.0-rc.1", true},
+		{"v1.4.2", true},
+		{"v1.4.3", false},
+	} {
+		if got := isRetracted(retracted, tt.version); got != tt.want {
+			t.Errorf("isRetracted(%q) = %t, want %t", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestRetractions(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	// v1.3.0 is in its cooldown, so its retractions aren't honored
+	times := map[string]time.Time{
+		"v1.0.0": time.Now().Add(-40 * 24 * time.Hour),
+		"v1.1.0": time.Now().Add(-30 * 24 * time.Hour),
+		"v1.2.0": time.Now().Add(-20 * 24 * time.Hour),
+		"v1.3.0": time.Now().Add(-1 * time.Hour),
+	}
+	mods := map[string]string{
+		"/example.com/module/@v/v1.2.0.mod": "module example.com/module\n\nretract v1.1.0 // broken\n",
+		"/example.com/module/@v/v1.3.0.mod": "module example.com/module\n\nretract [v1.0.0, v1.2.0]\n",
+		"/example.com/self/@v/v1.2.0.mod":   "module example.com/self\n\nretract (\n\tv1.2.0 // published accidentally\n\tv1.1.0\n)\n",
+	}
+	var modFetches atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			fmt.Fprint(w, "v1.0.0\nv1.1.0\nv1.2.0\nv1.3.0\n")
+		case strings.HasSuffix(r.URL.Path, "/@latest"):
+			json.NewEncoder(w).Encode(VersionInfo{Version: "v1.3.0", Time: times["v1.3.0"]})
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: times[version]})
+		case strings.HasSuffix(r.URL.Path, ".mod"):
+			modFetches.Add(1)
+			mod, ok := mods[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, mod)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retractCache, err := lru.New[string, []modfile.VersionInterval](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		retractCache:    retractCache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+		listConcurrency: 4,
+		listTimeout:     30 * time.Second,
+	}
+
+	for _, tt := range []struct {
+		desc       string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantList   string
+	}{{
+		desc:       "list",
+		path:       "/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.2.0\n",
+	}, {
+		desc:       "latest",
+		path:       "/example.com/module/@latest",
+		wantStatus: http.StatusOK,
+		wantBody:   `"Version":"v1.2.0"`,
+	}, {
+		desc:       "longer cooldown uses an older go.mod",
+		path:       "/25d/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0\n",
+	}, {
+		desc:       "latest eligible version retracts itself",
+		path:       "/example.com/self/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\n",
+	}, {
+		desc:       "latest skips self-retracted version",
+		path:       "/example.com/self/@latest",
+		wantStatus: http.StatusOK,
+		wantBody:   `"Version":"v1.0.0"`,
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body: got %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantList != "" && w.Body.String() != tt.wantList {
+				t.Errorf("list: got %q, want %q", w.Body.String(), tt.wantList)
+			}
+		})
+	}
+
+	// Each go.mod is fetched once: example.com/module v1.2.0 and v1.1.0, and
+	// example.com/self v1.2.0.
+	if got := modFetches.Load(); got != 3 {
+		t.Errorf("go.mod fetches: got %d, want 3", got)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// rule is a compiled CEL expression deciding whether a version is eligible
+// to be served. Expressions can use these variables:
+//
+//	module    string    the module path, e.g. "golang.org/x/net"
+//	version   object    the version being considered (see ruleVersion)
+//	time      timestamp the time the version's cooldown is measured from
+//	age       duration  how long ago time was
+//	cooldown  duration  the cooldown that would otherwise apply
+//	request   map       request metadata: path, host, userAgent, remoteAddr
+//
+// and days(int), which returns a duration of that many days. For example:
+//
+//	module.startsWith("github.com/ourorg/") ||
+//	  (version.isPrerelease ? age > days(60) : age > days(14))
+//
+// The expression must evaluate to a bool.
+type rule struct {
+	source  string
+	program cel.Program
+}
+
+// ruleVersion is the type of the version variable in rule expressions.
+type ruleVersion struct {
+	Raw            string `cel:"raw"`
+	Major          int64  `cel:"major"`
+	Minor          int64  `cel:"minor"`
+	Patch          int64  `cel:"patch"`
+	Prerelease     string `cel:"prerelease"`
+	IsPrerelease   bool   `cel:"isPrerelease"`
+	IsPseudo       bool   `cel:"isPseudo"`
+	IsIncompatible bool   `cel:"isIncompatible"`
+}
+
+// ruleInput holds the values a rule is evaluated against.
+type ruleInput struct {
+	module   string
+	version  string
+	time     time.Time
+	now      time.Time
+	cooldown time.Duration
+	request  map[string]string
+}
+
+var ruleEnv = sync.OnceValues(func() (*cel.Env, error) {
+	// NativeTypes names types after the last element of their package path,
+	// which is "main" in the binary but not in tests.
+	versionType := reflect.TypeFor[ruleVersion]()
+	versionTypeName := path.Base(versionType.PkgPath()) + "." + versionType.Name()
+
+	return cel.NewEnv(
+		ext.NativeTypes(versionType, ext.ParseStructTags(true)),
+		cel.Variable("module", cel.StringType),
+		cel.Variable("version", cel.ObjectType(versionTypeName)),
+		cel.Variable("time", cel.TimestampType),
+		cel.Variable("age", cel.DurationType),
+		cel.Variable("cooldown", cel.DurationType),
+		cel.Variable("request", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Function("days",
+			cel.Overload("days_int", []*cel.Type{cel.IntType}, cel.DurationType,
+				cel.UnaryBinding(func(v ref.Val) ref.Val {
+					return types.Duration{Duration: time.Duration(v.(types.Int)) * 24 * time.Hour}
+				}),
+			),
+		),
+	)
+})
+
+// compileRule parses and type-checks a rule expression.
+func compileRule(source string) (*rule, error) {
+	env, err := ruleEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rule environment: %w", err)
+	}
+	ast, iss := env.Compile(source)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("invalid rule: %w", iss.Err())
+	}
+	if !ast.OutputType().IsExactType(cel.BoolType) {
+		return nil, fmt.Errorf("rule must evaluate to bool, not %s", ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule: %w", err)
+	}
+	return &rule{source: source, program: program}, nil
+}
+
+// Eval reports whether the rule allows the version described by in.
+func (r *rule) Eval(in ruleInput) (bool, error) {
+	out, _, err := r.program.Eval(map[string]any{
+		"module":   in.module,
+		"version":  newRuleVersion(in.version),
+		"time":     in.time,
+		"age":      in.now.Sub(in.time),
+		"cooldown": in.cooldown,
+		"request":  in.request,
+	})
+	if err != nil {
+		return false, err
+	}
+	allowed, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("rule evaluated to %v, not bool", out)
+	}
+	return allowed, nil
+}
+
+func newRuleVersion(v string) *ruleVersion {
+	rv := &ruleVersion{
+		Raw:            v,
+		Prerelease:     strings.TrimPrefix(semver.Prerelease(v), "-"),
+		IsPseudo:       module.IsPseudoVersion(v),
+		IsIncompatible: semver.Build(v) == "+incompatible",
+	}
+	rv.IsPrerelease = rv.Prerelease != ""
+
+	// semver.Canonical fills in missing minor and patch numbers
+	core := strings.TrimPrefix(semver.Canonical(v), "v")
+	core, _, _ = strings.Cut(core, "-")
+	core, _, _ = strings.Cut(core, "+")
+	if parts := strings.Split(core, "."); len(parts) == 3 {
+		rv.Major, _ = strconv.ParseInt(parts[0], 10, 64)
+		rv.Minor, _ = strconv.ParseInt(parts[1], 10, 64)
+		rv.Patch, _ = strconv.ParseInt(parts[2], 10, 64)
+	}
+	return rv
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+func TestCompileRuleErrors(t *testing.T) {
+	for _, tt := range []struct {
+		desc string
+		rule string
+	}{
+		{"syntax error", "module.startsWith("},
+		{"unknown variable", "pkg == 'foo'"},
+		{"type error", "age > 14"},
+		{"not a bool", "module"},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := compileRule(tt.rule); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+
+	if _, err := parsePolicy([]byte("rule: age > 14\n")); err == nil {
+		t.Errorf("expected invalid rule to fail policy parsing")
+	}
+}
+
+func TestRuleEval(t *testing.T) {
+	r, err := compileRule(`module.startsWith("github.com/ourorg/") ||
+		(version.isPrerelease ? age > days(60) : age > days(14))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	day := 24 * time.Hour
+	for _, tt := range []struct {
+		module  string
+		version string
+		age     time.Duration
+		want    bool
+	}{
+		{"github.com/ourorg/repo", "v1.0.0", 0, true},
+		{"example.com/module", "v1.0.0", 15 * day, true},
+		{"example.com/module", "v1.0.0", 13 * day, false},
+		{"example.com/module", "v1.1.0-rc.1", 30 * day, false},
+		{"example.com/module", "v1.1.0-rc.1", 61 * day, true},
+		{"example.com/module", "v0.0.0-20240101000000-abcdefabcdef", 30 * day, false},
+	} {
+		got, err := r.Eval(ruleInput{
+			module:  tt.module,
+			version: tt.version,
+			time:    now.Add(-tt.age),
+			now:     now,
+		})
+		if err != nil {
+			t.Errorf("Eval(%s@%s): %v", tt.module, tt.version, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%s@%s, age %v) = %t, want %t", tt.module, tt.version, tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestRuleVersion(t *testing.T) {
+	for _, tt := range []struct {
+		version string
+		want    ruleVersion
+	}{
+		{"v1.2.3", ruleVersion{Raw: "v1.2.3", Major: 1, Minor: 2, Patch: 3}},
+		{"v1.2", ruleVersion{Raw: "v1.2", Major: 1, Minor: 2}},
+		{"v2.0.0-beta.1", ruleVersion{Raw: "v2.0.0-beta.1", Major: 2, Prerelease: "beta.1", IsPrerelease: true}},
+		{"v3.1.0+incompatible", ruleVersion{Raw: "v3.1.0+incompatible", Major: 3, Minor: 1, IsIncompatible: true}},
+		{"v0.0.0-20240101000000-abcdefabcdef", ruleVersion{
+			Raw:          "v0.0.0-20240101000000-abcdefabcdef",
+			Prerelease:   "20240101000000-abcdefabcdef",
+			IsPrerelease: true,
+			IsPseudo:     true,
+		}},
+	} {
+		if got := *newRuleVersion(tt.version); got != tt.want {
+			t.Errorf("newRuleVersion(%q) = %+v, want %+v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestRuleProxy(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	// Releases are 20 days old, prereleases 30 days old
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			w.Write([]byte("v1.0.0\nv1.1.0-rc.1\n"))
+		case strings.HasSuffix(r.URL.Path, "/@latest"):
+			json.NewEncoder(w).Encode(VersionInfo{Version: "v1.1.0-rc.1", Time: time.Now().Add(-30 * 24 * time.Hour)})
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			age := 20 * 24 * time.Hour
+			if strings.Contains(version, "-") {
+				age = 30 * 24 * time.Hour
+			}
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: time.Now().Add(-age)})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	policy, err := parsePolicy([]byte(`
+rule: |
+  module.startsWith("github.com/ourorg/") ||
+  request.userAgent == "trusted-bot" ||
+  (version.isPrerelease ? age > days(60) : age >= cooldown)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		defaultCooldown: fixedCooldown(14 * 24 * time.Hour),
+		listConcurrency: 4,
+		listTimeout:     30 * time.Second,
+	}
+	proxy.policy.Store(policy)
+
+	for _, tt := range []struct {
+		desc       string
+		path       string
+		userAgent  string
+		wantStatus int
+		wantBody   string
+		wantList   string
+	}{{
+		desc:       "list drops prerelease",
+		path:       "/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\n",
+	}, {
+		desc:       "list for trusted org",
+		path:       "/github.com/ourorg/repo/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0-rc.1\n",
+	}, {
+		desc:       "info for release",
+		path:       "/example.com/module/@v/v1.0.0.info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "info for prerelease",
+		path:       "/example.com/module/@v/v1.1.0-rc.1.info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "cooldown from URL is passed to the rule",
+		path:       "/30d/example.com/module/@v/v1.0.0.info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "latest falls back to release",
+		path:       "/example.com/module/@latest",
+		wantStatus: http.StatusOK,
+		wantBody:   `"Version":"v1.0.0"`,
+	}, {
+		desc:       "download of prerelease",
+		path:       "/example.com/module/@v/v1.1.0-rc.1.zip",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "request metadata",
+		path:       "/example.com/module/@v/v1.1.0-rc.1.zip",
+		userAgent:  "trusted-bot",
+		wantStatus: http.StatusTemporaryRedirect,
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			if tt.userAgent != "" {
+				req.Header.Set("User-Agent", tt.userAgent)
+			}
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body: got %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantList != "" && w.Body.String() != tt.wantList {
+				t.Errorf("list: got %q, want %q", w.Body.String(), tt.wantList)
+			}
+		})
+	}
+}
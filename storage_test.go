@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/minio/minio-go/v7"
+)
+
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+	key := "example.com/module/@v/v1.0.0.mod"
+
+	if _, err := s.Get(ctx, key); !isNotExist(err) {
+		t.Fatalf("Get before Put: got %v, want not exist", err)
+	}
+
+	content := "module example.com/module\n"
+	if err := s.Put(ctx, key, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	rc, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer rc.Close()
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != content {
+		t.Errorf("Get: got %q, want %q", got, content)
+	}
+}
+
+func TestFileStorage(t *testing.T) {
+	s, err := newFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, s)
+
+	for _, key := range []string{"", "../escape", "a/../../escape", "/abs"} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x"), 1); err == nil {
+			t.Errorf("Put(%q): expected error", key)
+		}
+	}
+}
+
+// TestS3Storage runs against a local MinIO server, e.g.:
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	MINIO_ENDPOINT=localhost:9000 go test -run TestS3Storage
+func TestS3Storage(t *testing.T) {
+	endpoint := os.Getenv("MINIO_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("MINIO_ENDPOINT not set")
+	}
+	ctx := context.Background()
+
+	s, err := newS3Storage(endpoint, "go-cooldown-test", "prefix", "minioadmin", "minioadmin", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.client.MakeBucket(ctx, s.bucket, minio.MakeBucketOptions{}); err != nil {
+		if exists, _ := s.client.BucketExists(ctx, s.bucket); !exists {
+			t.Fatal(err)
+		}
+	}
+	s.prefix = t.Name() + "-" + time.Now().Format("20060102150405.000000000")
+	testStorage(t, s)
+}
+
+func TestProxyStorage(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	var zipFetches atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/module/@v/v1.0.0.info":
+			info := VersionInfo{
+				Version: "v1.0.0",
+				Time:    time.Now().Add(-30 * 24 * time.Hour),
+			}
+			json.NewEncoder(w).Encode(info)
+
+		case "/example.com/module/@v/v1.0.0.zip":
+			zipFetches.Add(1)
+			w.Write([]byte("fake zip content"))
+
+		case "/example.com/module/@v/v1.0.0.mod":
+			w.Write([]byte("module example.com/module\n\ngo 1.21\n"))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage, err := newFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+		storage:         storage,
+	}
+
+	for _, tt := range []struct {
+		desc       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			desc:       "zip is fetched and served",
+			path:       "/example.com/module/@v/v1.0.0.zip",
+			wantStatus: http.StatusOK,
+			wantBody:   "fake zip content",
+		},
+		{
+			desc:       "zip is served from storage",
+			path:       "/30d/example.com/module/@v/v1.0.0.zip",
+			wantStatus: http.StatusOK,
+			wantBody:   "fake zip content",
+		},
+		{
+			desc:       "mod is fetched and served",
+			path:       "/example.com/module/@v/v1.0.0.mod",
+			wantStatus: http.StatusOK,
+			wantBody:   "module example.com/module\n\ngo 1.21\n",
+		},
+		{
+			desc:       "unknown version is not found",
+			path:       "/example.com/module/@v/v9.9.9.zip",
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body: got %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	if got := zipFetches.Load(); got != 1 {
+		t.Errorf("upstream zip fetches: got %d, want 1", got)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// handleSumdb proxies checksum database requests:
+//
+//	/sumdb/<name>/supported
+//	/sumdb/<name>/latest
+//	/sumdb/<name>/lookup/<module>@<version>
+//	/sumdb/<name>/tile/<H>/<L>/<K>[.p/<W>]
+//
+// Tiles are immutable, so they're cached after the first fetch. Everything
+// else is passed through to upstream unchanged.
+func (p *Proxy) handleSumdb(ctx context.Context, w http.ResponseWriter, path string) {
+	log := clog.FromContext(ctx)
+
+	// path is /sumdb/<name>/<rest>
+	parts := strings.SplitN(strings.TrimPrefix(path, "/sumdb/"), "/", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	name, rest := parts[0], parts[1]
+	log = log.With("sumdb", name)
+
+	switch {
+	case rest == "supported", rest == "latest", strings.HasPrefix(rest, "lookup/"):
+		p.proxyRequest(ctx, w, path)
+	case strings.HasPrefix(rest, "tile/"):
+		p.handleTile(ctx, w, path)
+	default:
+		log.InfoContext(ctx, "unknown sumdb request", "path", path)
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (p *Proxy) handleTile(ctx context.Context, w http.ResponseWriter, path string) {
+	log := clog.FromContext(ctx)
+
+	if p.tileCache != nil {
+		if tile, ok := p.tileCache.Get(path); ok {
+			log.DebugContext(ctx, "tile cache hit", "path", path)
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.WriteHeader(http.StatusOK)
+			w.Write(tile)
+			return
+		}
+	}
+
+	// Concurrent misses for the same tile share a single upstream fetch
+	resp, err := p.fetchUpstream(ctx, path)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to fetch tile", "error", err)
+		http.Error(w, "failed to fetch tile", http.StatusBadGateway)
+		return
+	}
+
+	if resp.status != http.StatusOK {
+		log.WarnContext(ctx, "upstream returned non-200", "status", resp.status)
+		w.WriteHeader(resp.status)
+		w.Write(resp.body)
+		return
+	}
+
+	tile := resp.body
+	if p.tileCache != nil {
+		p.tileCache.Add(path, tile)
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	w.Write(tile)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+func TestSumdb(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	var tileFetches atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sumdb/sum.golang.org/supported":
+			w.WriteHeader(http.StatusOK)
+
+		case "/sumdb/sum.golang.org/lookup/example.com/module@v1.0.0":
+			w.Write([]byte("1234\nexample.com/module v1.0.0 h1:abc=\n"))
+
+		case "/sumdb/sum.golang.org/tile/8/0/001":
+			tileFetches.Add(1)
+			w.Write([]byte("tile data"))
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tileCache, err := lru.New[string, []byte](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		tileCache:       tileCache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+	}
+
+	for _, tt := range []struct {
+		desc       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			desc:       "supported",
+			path:       "/sumdb/sum.golang.org/supported",
+			wantStatus: http.StatusOK,
+		},
+		{
+			desc:       "supported with cooldown prefix",
+			path:       "/30d/sumdb/sum.golang.org/supported",
+			wantStatus: http.StatusOK,
+		},
+		{
+			desc:       "unsupported sumdb",
+			path:       "/sumdb/sum.example.com/supported",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			desc:       "lookup",
+			path:       "/1y/sumdb/sum.golang.org/lookup/example.com/module@v1.0.0",
+			wantStatus: http.StatusOK,
+			wantBody:   "1234\nexample.com/module v1.0.0 h1:abc=\n",
+		},
+		{
+			desc:       "tile",
+			path:       "/sumdb/sum.golang.org/tile/8/0/001",
+			wantStatus: http.StatusOK,
+			wantBody:   "tile data",
+		},
+		{
+			desc:       "cached tile",
+			path:       "/7d/sumdb/sum.golang.org/tile/8/0/001",
+			wantStatus: http.StatusOK,
+			wantBody:   "tile data",
+		},
+		{
+			desc:       "missing tile",
+			path:       "/sumdb/sum.golang.org/tile/8/0/002",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			desc:       "unknown sumdb endpoint",
+			path:       "/sumdb/sum.golang.org/bogus",
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body: got %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	if got := tileFetches.Load(); got != 1 {
+		t.Errorf("upstream tile fetches: got %d, want 1", got)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// changeTier classifies a version by how much it changes from the previous
+// eligible version of its module: a patch, minor or major update.
+type changeTier int
+
+const (
+	tierPatch changeTier = iota
+	tierMinor
+	tierMajor
+	numTiers
+)
+
+func (t changeTier) String() string {
+	return [...]string{"patch", "minor", "major"}[t]
+}
+
+// parseTier parses the name of a tier, like "patch".
+func parseTier(s string) (changeTier, bool) {
+	for t := range numTiers {
+		if t.String() == s {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// tieredCooldowns holds a cooldown for each tier, set by PATCH_COOLDOWN,
+// MINOR_COOLDOWN and MAJOR_COOLDOWN, or patch=, minor= and major= path
+// options. Tiers without one get the usual cooldown.
+type tieredCooldowns [numTiers]*Cooldown
+
+func (tc tieredCooldowns) isZero() bool {
+	return tc == tieredCooldowns{}
+}
+
+// tierCutoff is the cutoff for versions in one tier.
+type tierCutoff struct {
+	cutoff           time.Time
+	prereleaseCutoff time.Time
+	applied          string
+}
+
+// classifyChange classifies version as an update from previous. A version
+// with no eligible version before it is new to everyone using the proxy, so
+// it's treated as a major update.
+func classifyChange(previous, version string) changeTier {
+	switch {
+	case previous == "" || semver.Major(previous) != semver.Major(version):
+		return tierMajor
+	case semver.MajorMinor(previous) != semver.MajorMinor(version):
+		return tierMinor
+	default:
+		return tierPatch
+	}
+}
+
+// tierCutoffs returns the cutoffs for each tier of modulePath, or nil if no
+// tier has a cooldown. A tier's cooldown from the URL replaces the URL
+// prefix's for that tier, and a configured one replaces DEFAULT_COOLDOWN,
+// so either way a per-module policy still applies as usual.
+func (p *Proxy) tierCutoffs(policy *Policy, modulePath string, now, cutoff time.Time, cooldown string, fromURL bool, opts requestOptions) []tierCutoff {
+	if p.tiers.isZero() && opts.tiers.isZero() {
+		return nil
+	}
+	tiers := make([]tierCutoff, numTiers)
+	for t := range numTiers {
+		var tc tierCutoff
+		switch {
+		case opts.tiers[t] != nil:
+			c := *opts.tiers[t]
+			tc.cutoff, tc.applied = p.effectiveCutoff(policy, modulePath, now, c.Cutoff(now, p.calendar), c.String(), true, p.defaultCooldown)
+		case fromURL || p.tiers[t] == nil:
+			tc.cutoff, tc.applied = p.effectiveCutoff(policy, modulePath, now, cutoff, cooldown, fromURL, p.defaultCooldown)
+		default:
+			c := *p.tiers[t]
+			tc.cutoff, tc.applied = p.effectiveCutoff(policy, modulePath, now, c.Cutoff(now, p.calendar), c.String(), false, c)
+		}
+		tc.prereleaseCutoff, _ = p.prereleaseCutoff(now, tc.cutoff, opts.prerelease)
+		tiers[t] = tc
+	}
+	return tiers
+}
+
+// eligibleVersions reports which of versions may be served, given their
+// infos, which are nil for versions that couldn't be fetched. With tiered
+// cooldowns, versions are checked in semver order, so that each can be
+// classified against the previous eligible one.
+func (p *Proxy) eligibleVersions(ctx context.Context, vc *versionCheck, versions []string, infos []*VersionInfo) []bool {
+	order := make([]int, len(versions))
+	for i := range order {
+		order[i] = i
+	}
+	if vc.tiers != nil {
+		slices.SortStableFunc(order, func(i, j int) int {
+			return semver.Compare(versions[i], versions[j])
+		})
+	}
+
+	ok := make([]bool, len(versions))
+	var previous string
+	for _, i := range order {
+		if infos[i] == nil {
+			continue
+		}
+		if p.eligible(ctx, vc, previous, versions[i], infos[i]) {
+			ok[i] = true
+			previous = versions[i]
+		}
+	}
+	return ok
+}
+
+// tierWalk is the outcome of one eligibility pass over a module's version
+// list in semver order, which tiered cooldowns need to classify versions.
+type tierWalk struct {
+	eligible []string // in ascending order
+}
+
+// walkTiers checks the versions in modulePath's version list in semver
+// order, stopping before the version before, if it's set.
+func (p *Proxy) walkTiers(ctx context.Context, vc *versionCheck, modulePath, before string) (*tierWalk, error) {
+	resp, err := p.fetchList(ctx, modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch version list: %w", err)
+	}
+	if resp.status != http.StatusOK {
+		return &tierWalk{}, nil
+	}
+	var versions []string
+	for _, v := range strings.Split(strings.TrimSpace(string(resp.body)), "\n") {
+		if semver.IsValid(v) && (before == "" || semver.Compare(v, before) < 0) {
+			versions = append(versions, v)
+		}
+	}
+
+	// Don't report security fixes among the versions walked, since they
+	// aren't necessarily being served.
+	check := *vc
+	check.header = http.Header{}
+	infos := p.fetchVersionInfos(ctx, modulePath, versions)
+	var tw tierWalk
+	for i, ok := range p.eligibleVersions(ctx, &check, versions, infos) {
+		if ok {
+			tw.eligible = append(tw.eligible, versions[i])
+		}
+	}
+	slices.SortFunc(tw.eligible, semver.Compare)
+	return &tw, nil
+}
+
+// previous returns the newest eligible version before version, or "" if
+// there's none. tw may be nil, for when tiers aren't in use.
+func (tw *tierWalk) previous(version string) string {
+	if tw == nil {
+		return ""
+	}
+	var previous string
+	for _, v := range tw.eligible {
+		if semver.Compare(v, version) >= 0 {
+			break
+		}
+		previous = v
+	}
+	return previous
+}
+
+// previousEligible returns the newest eligible version of modulePath before
+// version, which version is classified against with tiered cooldowns, or ""
+// if there's none. Without tiered cooldowns, it's always "".
+func (p *Proxy) previousEligible(ctx context.Context, vc *versionCheck, modulePath, version string) (string, error) {
+	if vc.tiers == nil {
+		return "", nil
+	}
+	tw, err := p.walkTiers(ctx, vc, modulePath, version)
+	if err != nil {
+		return "", err
+	}
+	return tw.previous(version), nil
+}
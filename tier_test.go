@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+func TestClassifyChange(t *testing.T) {
+	for _, tt := range []struct {
+		previous, version string
+		want              changeTier
+	}{
+		{"", "v1.0.0", tierMajor},
+		{"v1.0.0", "v1.0.1", tierPatch},
+		{"v1.0.0", "v1.1.0", tierMinor},
+		{"v1.0.0", "v2.0.0+incompatible", tierMajor},
+		{"v0.9.0", "v1.0.0", tierMajor},
+		{"v1.1.0-rc.1", "v1.1.0", tierPatch},
+		{"v1.0.0", "v1.1.0-rc.1", tierMinor},
+	} {
+		if got := classifyChange(tt.previous, tt.version); got != tt.want {
+			t.Errorf("classifyChange(%q, %q) = %v, want %v", tt.previous, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestTieredCooldowns(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	day := 24 * time.Hour
+	times := map[string]time.Time{
+		"v1.0.0": time.Now().Add(-100 * day),
+		"v1.0.1": time.Now().Add(-5 * day),
+		"v1.1.0": time.Now().Add(-20 * day),
+		"v1.1.1": time.Now().Add(-2 * day),
+	}
+	var listFetches atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			listFetches.Add(1)
+			w.Write([]byte("v1.0.0\nv1.0.1\nv1.1.0\nv1.1.1\n"))
+		case strings.HasSuffix(r.URL.Path, "/@latest"):
+			json.NewEncoder(w).Encode(VersionInfo{Version: "v1.1.1", Time: times["v1.1.1"]})
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: times[version]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	for _, tt := range []struct {
+		desc       string
+		configured tieredCooldowns
+		policy     string
+		path       string
+		wantStatus int
+		wantList   string
+		wantLatest string
+		// The list isn't cached here, so this bounds how many times it's
+		// checked
+		maxListFetches int32
+	}{{
+		desc:       "every tier",
+		path:       "/patch=3d/minor=14d/major=30d/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.0.1\nv1.1.0\n",
+	}, {
+		desc:       "versions are classified against the previous eligible version",
+		path:       "/patch=3d/minor=30d/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.0.1\n",
+	}, {
+		desc:       "info",
+		path:       "/patch=1d/example.com/module/@v/v1.1.1.info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "info classified against an earlier version",
+		path:       "/patch=1d/minor=30d/example.com/module/@v/v1.1.1.info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "download",
+		path:       "/patch=1d/minor=30d/example.com/module/@v/v1.1.1.zip",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "latest",
+		path:       "/patch=1d/minor=30d/example.com/module/@latest",
+		wantStatus: http.StatusOK,
+		wantLatest: "v1.0.1",
+		// Once up front, and once to look for an older version
+		maxListFetches: 2,
+	}, {
+		desc:       "configured",
+		configured: tieredCooldowns{tierPatch: ptr(fixedCooldown(day))},
+		path:       "/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.0.1\nv1.1.0\nv1.1.1\n",
+	}, {
+		desc:       "URL prefix replaces configured tiers",
+		configured: tieredCooldowns{tierPatch: ptr(fixedCooldown(day))},
+		path:       "/7d/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0\n",
+	}, {
+		desc:       "policy replaces configured tiers",
+		configured: tieredCooldowns{tierPatch: ptr(fixedCooldown(day))},
+		policy:     "cooldowns:\n  '*': 7d\n",
+		path:       "/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.1.0\n",
+	}, {
+		desc:       "URL tiers can't loosen the policy",
+		policy:     "cooldowns:\n  '*': 7d\n",
+		path:       "/patch=1d/example.com/module/@v/v1.1.1.info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "invalid option",
+		path:       "/patch=soon/example.com/module/@v/list",
+		wantStatus: http.StatusBadRequest,
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			listFetches.Store(0)
+			cache, err := lru.New[string, *VersionInfo](100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proxy := &Proxy{
+				upstream:        upstream.URL,
+				client:          &http.Client{Timeout: 30 * time.Second},
+				cache:           cache,
+				defaultCooldown: fixedCooldown(7 * day),
+				tiers:           tt.configured,
+				listConcurrency: 4,
+				listTimeout:     30 * time.Second,
+			}
+			if tt.policy != "" {
+				policy, err := parsePolicy([]byte(tt.policy))
+				if err != nil {
+					t.Fatal(err)
+				}
+				proxy.policy.Store(policy)
+			}
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantList != "" && w.Body.String() != tt.wantList {
+				t.Errorf("list: got %q, want %q", w.Body.String(), tt.wantList)
+			}
+			if tt.wantLatest != "" {
+				var info VersionInfo
+				if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+					t.Fatal(err)
+				}
+				if info.Version != tt.wantLatest {
+					t.Errorf("latest: got %s, want %s", info.Version, tt.wantLatest)
+				}
+			}
+			if tt.maxListFetches > 0 && listFetches.Load() > tt.maxListFetches {
+				t.Errorf("list fetches: got %d, want at most %d", listFetches.Load(), tt.maxListFetches)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
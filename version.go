@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// sortLatestCandidates returns the versions from an @v/list response in the
+// order the go command would prefer them for @latest. If incompatibleLast is set,
+// that's:
+//
+//  1. compatible releases, newest first
+//  2. compatible prereleases, newest first
+//  3. +incompatible releases, newest first
+//  4. +incompatible prereleases, newest first
+//
+// Otherwise, +incompatible versions are ordered with the rest: releases,
+// then prereleases, newest first.
+//
+// Invalid versions and pseudo-versions are dropped, since @v/list shouldn't
+// contain them and @latest never selects them when tags exist.
+func sortLatestCandidates(versions []string, incompatibleLast bool) []string {
+	var candidates []string
+	for _, v := range versions {
+		v = strings.TrimSpace(v)
+		if !semver.IsValid(v) || module.IsPseudoVersion(v) {
+			continue
+		}
+		candidates = append(candidates, v)
+	}
+
+	rank := func(v string) int {
+		r := 0
+		if incompatibleLast && isIncompatible(v) {
+			r += 2
+		}
+		if semver.Prerelease(v) != "" {
+			r++
+		}
+		return r
+	}
+	slices.SortStableFunc(candidates, func(a, b string) int {
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra - rb
+		}
+		return semver.Compare(b, a)
+	})
+	return candidates
+}
+
+func isIncompatible(v string) bool {
+	return semver.Build(v) == "+incompatible"
+}
+
+// latestCandidates picks how to order versions of modulePath for @latest
+// (see sortLatestCandidates). The go command only passes over +incompatible
+// versions if the latest compatible version has a go.mod file; modules like
+// github.com/docker/docker have v1 tags without one alongside
+// +incompatible ones, and then every version is ordered by semver. So if
+// there are +incompatible versions, this fetches that go.mod to decide.
+func (p *Proxy) latestCandidates(ctx context.Context, modulePath string, versions []string) []string {
+	var latestCompatible string
+	hasIncompatible := false
+	for _, v := range versions {
+		v = strings.TrimSpace(v)
+		if !semver.IsValid(v) || module.IsPseudoVersion(v) {
+			continue
+		}
+		if isIncompatible(v) {
+			hasIncompatible = true
+		} else if semver.Compare(v, latestCompatible) > 0 {
+			latestCompatible = v
+		}
+	}
+	if !hasIncompatible || latestCompatible == "" {
+		return sortLatestCandidates(versions, true)
+	}
+	return sortLatestCandidates(versions, p.hasGoMod(ctx, modulePath, latestCompatible))
+}
+
+// hasGoMod reports whether modulePath@version has a go.mod file of its own.
+// For versions without one, proxies serve a synthesized go.mod with only a
+// module directive, which is how the go command tells them apart too. If
+// the go.mod can't be fetched, it's assumed to exist.
+func (p *Proxy) hasGoMod(ctx context.Context, modulePath, version string) bool {
+	log := clog.FromContext(ctx)
+	key := modulePath + "@" + version
+
+	if p.goModCache != nil {
+		if has, ok := p.goModCache.Get(key); ok {
+			return has
+		}
+	}
+
+	escaped, err := module.EscapeVersion(version)
+	if err != nil {
+		log.WarnContext(ctx, "invalid version", "version", version, "error", err)
+		return true
+	}
+	resp, err := p.fetchUpstream(ctx, fmt.Sprintf("/%s/@v/%s.mod", modulePath, escaped))
+	if err != nil {
+		log.WarnContext(ctx, "failed to fetch go.mod", "version", version, "error", err)
+		return true
+	}
+	if resp.status != http.StatusOK {
+		log.WarnContext(ctx, "upstream returned non-200 for go.mod", "version", version, "status", resp.status)
+		return true
+	}
+
+	path := modulePath
+	if unescaped, err := module.UnescapePath(modulePath); err == nil {
+		path = unescaped
+	}
+	has := string(resp.body) != "module "+modfile.AutoQuote(path)+"\n"
+	if p.goModCache != nil {
+		p.goModCache.Add(key, has)
+	}
+	return has
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/mod/modfile"
+)
+
+func TestSortLatestCandidates(t *testing.T) {
+	for _, tt := range []struct {
+		desc     string
+		versions []string
+		noGoMod  bool // the latest compatible version has no go.mod
+		want     []string
+	}{{
+		desc:     "unsorted releases",
+		versions: []string{"v1.2.0", "v1.10.0", "v1.9.1", "v1.0.0"},
+		want:     []string{"v1.10.0", "v1.9.1", "v1.2.0", "v1.0.0"},
+	}, {
+		desc:     "releases before prereleases",
+		versions: []string{"v1.0.0", "v2.0.0-rc.1", "v1.1.0", "v1.2.0-beta"},
+		want:     []string{"v1.1.0", "v1.0.0", "v2.0.0-rc.1", "v1.2.0-beta"},
+	}, {
+		desc:     "compatible before incompatible if it has a go.mod",
+		versions: []string{"v2.0.0+incompatible", "v1.5.0", "v3.1.0+incompatible", "v1.6.0-rc.1"},
+		want:     []string{"v1.5.0", "v1.6.0-rc.1", "v3.1.0+incompatible", "v2.0.0+incompatible"},
+	}, {
+		desc:     "semver order if the latest compatible version has no go.mod",
+		versions: []string{"v1.13.1", "v20.10.0+incompatible", "v1.14.0-rc.1", "v17.0.0+incompatible", "v21.0.0-rc.1+incompatible"},
+		noGoMod:  true,
+		want:     []string{"v20.10.0+incompatible", "v17.0.0+incompatible", "v1.13.1", "v21.0.0-rc.1+incompatible", "v1.14.0-rc.1"},
+	}, {
+		desc:     "incompatible prereleases last",
+		versions: []string{"v3.0.0-rc.1+incompatible", "v2.0.0+incompatible"},
+		want:     []string{"v2.0.0+incompatible", "v3.0.0-rc.1+incompatible"},
+	}, {
+		desc:     "pseudo-versions and invalid versions are dropped",
+		versions: []string{"v0.0.0-20240101120000-abcdef123456", "v1.0.0", "", "bogus", " v1.0.1 "},
+		want:     []string{"v1.0.1", "v1.0.0"},
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := sortLatestCandidates(tt.versions, !tt.noGoMod)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sortLatestCandidates(%q) = %q, want %q", tt.versions, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatestUnsortedList(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	ages := map[string]int{
+		"v1.10.0":     20,
+		"v1.2.0":      60,
+		"v1.9.0":      30,
+		"v2.0.0-rc.1": 10,
+		"v1.11.0":     1,
+	}
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/module/@v/list":
+			for _, v := range []string{"v1.10.0", "v2.0.0-rc.1", "v1.2.0", "v1.11.0", "v1.9.0"} {
+				fmt.Fprintln(w, v)
+			}
+		case "/example.com/module/@latest":
+			json.NewEncoder(w).Encode(VersionInfo{
+				Version: "v1.11.0",
+				Time:    time.Now().Add(-24 * time.Hour),
+			})
+		default:
+			version := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/example.com/module/@v/"), ".info")
+			days, ok := ages[version]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(VersionInfo{
+				Version: version,
+				Time:    time.Now().Add(-time.Duration(days) * 24 * time.Hour),
+			})
+		}
+	}))
+	defer upstream.Close()
+
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+	}
+
+	req := httptest.NewRequest("GET", "/example.com/module/@latest", nil)
+	req = req.WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	proxy.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want %d", w.Code, http.StatusOK)
+	}
+	var info VersionInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	// v1.11.0 is too new, and v2.0.0-rc.1 is a prerelease.
+	if info.Version != "v1.10.0" {
+		t.Errorf("latest: got %s, want v1.10.0", info.Version)
+	}
+}
+
+func TestLatestIncompatible(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	ages := map[string]int{
+		"v1.13.1":               400,
+		"v17.0.0+incompatible":  300,
+		"v20.10.0+incompatible": 1,
+	}
+
+	for _, tt := range []struct {
+		desc  string
+		goMod string
+		want  string
+	}{{
+		// Like github.com/docker/docker, whose v1 tags predate modules
+		desc:  "no go.mod",
+		goMod: "module example.com/Module\n",
+		want:  "v17.0.0+incompatible",
+	}, {
+		desc:  "go.mod",
+		goMod: "module example.com/Module\n\ngo 1.21\n",
+		want:  "v1.13.1",
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			var modFetches atomic.Int32
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/example.com/!module/@v/list":
+					for v := range ages {
+						fmt.Fprintln(w, v)
+					}
+				case "/example.com/!module/@latest":
+					json.NewEncoder(w).Encode(VersionInfo{
+						Version: "v20.10.0+incompatible",
+						Time:    time.Now().Add(-24 * time.Hour),
+					})
+				case "/example.com/!module/@v/v1.13.1.mod":
+					modFetches.Add(1)
+					w.Write([]byte(tt.goMod))
+				default:
+					version := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/example.com/!module/@v/"), ".info")
+					days, ok := ages[version]
+					if !ok {
+						http.NotFound(w, r)
+						return
+					}
+					json.NewEncoder(w).Encode(VersionInfo{
+						Version: version,
+						Time:    time.Now().Add(-time.Duration(days) * 24 * time.Hour),
+					})
+				}
+			}))
+			defer upstream.Close()
+
+			cache, err := lru.New[string, *VersionInfo](100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			retractCache, err := lru.New[string, []modfile.VersionInterval](100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			goModCache, err := lru.New[string, bool](100)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proxy := &Proxy{
+				upstream:        upstream.URL,
+				client:          &http.Client{Timeout: 30 * time.Second},
+				cache:           cache,
+				retractCache:    retractCache,
+				goModCache:      goModCache,
+				defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+			}
+
+			// The second request is answered from the caches
+			var fetches int32
+			for i := range 2 {
+				req := httptest.NewRequest("GET", "/example.com/!module/@latest", nil)
+				req = req.WithContext(ctx)
+				w := httptest.NewRecorder()
+
+				proxy.ServeHTTP(w, req)
+
+				if w.Code != http.StatusOK {
+					t.Fatalf("status: got %d, want %d", w.Code, http.StatusOK)
+				}
+				var info VersionInfo
+				if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+					t.Fatal(err)
+				}
+				if info.Version != tt.want {
+					t.Errorf("latest: got %s, want %s", info.Version, tt.want)
+				}
+				if i == 0 {
+					fetches = modFetches.Load()
+				}
+			}
+			if fetches == 0 {
+				t.Errorf("go.mod of the latest compatible version wasn't checked")
+			}
+			if got := modFetches.Load(); got != fetches {
+				t.Errorf("go.mod fetches: got %d after the second request, want %d", got, fetches)
+			}
+		})
+	}
+}
+
+func TestPseudoVersions(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	old := time.Now().Add(-30 * 24 * time.Hour).UTC().Truncate(time.Second)
+	recent := time.Now().Add(-1 * 24 * time.Hour).UTC().Truncate(time.Second)
+	oldPseudo := "v0.0.0-" + old.Format("20060102150405") + "-abcdef123456"
+	recentPseudo := "v0.0.0-" + recent.Format("20060102150405") + "-abcdef123456"
+	missingPseudo := "v0.0.0-" + old.Format("20060102150405") + "-000000000000"
+
+	var upstreamTime time.Time
+	var infoFetches atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".info") {
+			infoFetches.Add(1)
+			if strings.Contains(r.URL.Path, missingPseudo) {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(VersionInfo{
+				Version: oldPseudo,
+				Time:    upstreamTime,
+			})
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer upstream.Close()
+
+	for _, tt := range []struct {
+		desc         string
+		version      string
+		verify       bool
+		upstreamTime time.Time
+		wantStatus   int
+		wantFetches  int32
+	}{
+		{
+			desc:        "old pseudo-version is served from upstream",
+			version:     oldPseudo,
+			wantStatus:  http.StatusOK,
+			wantFetches: 1,
+		},
+		{
+			desc:        "old pseudo-version missing upstream is not found",
+			version:     missingPseudo,
+			wantStatus:  http.StatusNotFound,
+			wantFetches: 1,
+		},
+		{
+			desc:        "recent pseudo-version is filtered locally",
+			version:     recentPseudo,
+			wantStatus:  http.StatusNotFound,
+			wantFetches: 0,
+		},
+		{
+			desc:         "verified pseudo-version matching upstream is allowed",
+			version:      oldPseudo,
+			verify:       true,
+			upstreamTime: old,
+			wantStatus:   http.StatusOK,
+			wantFetches:  1,
+		},
+		{
+			desc:         "verified pseudo-version newer upstream is filtered",
+			version:      oldPseudo,
+			verify:       true,
+			upstreamTime: recent,
+			wantStatus:   http.StatusNotFound,
+			wantFetches:  1,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			infoFetches.Store(0)
+			upstreamTime = tt.upstreamTime
+
+			cache, err := lru.New[string, *VersionInfo](100)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proxy := &Proxy{
+				upstream:             upstream.URL,
+				client:               &http.Client{Timeout: 30 * time.Second},
+				cache:                cache,
+				defaultCooldown:      fixedCooldown(7 * 24 * time.Hour),
+				verifyPseudoVersions: tt.verify,
+			}
+
+			req := httptest.NewRequest("GET", "/example.com/module/@v/"+tt.version+".info", nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := infoFetches.Load(); got != tt.wantFetches {
+				t.Errorf("upstream info fetches: got %d, want %d", got, tt.wantFetches)
+			}
+		})
+	}
+}
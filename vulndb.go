@@ -0,0 +1,265 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// vulnDB is an offline copy of an OSV vulnerability database, such as the
+// one exported by vuln.go.dev, indexed by module path.
+type vulnDB struct {
+	modules map[string][]osvAffected
+}
+
+// osvEntry is the subset of the OSV schema the proxy uses.
+// See https://ossf.github.io/osv-schema/.
+type osvEntry struct {
+	ID               string     `json:"id"`
+	Withdrawn        *time.Time `json:"withdrawn,omitempty"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity,omitempty"` // set by GitHub advisories
+	} `json:"database_specific"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []osvRange `json:"ranges"`
+	} `json:"affected"`
+}
+
+type osvRange struct {
+	Type   string `json:"type"`
+	Events []struct {
+		Introduced string `json:"introduced,omitempty"`
+		Fixed      string `json:"fixed,omitempty"`
+	} `json:"events"`
+}
+
+// osvAffected is one advisory's affected ranges for a module.
+type osvAffected struct {
+	id       string
+	severity vulnSeverity
+	ranges   []osvRange
+}
+
+// vulnSeverity is an advisory's severity. Advisories without a recognized
+// severity, like those in the Go vulnerability database, are unknownSeverity.
+type vulnSeverity int
+
+const (
+	unknownSeverity vulnSeverity = iota
+	lowSeverity
+	moderateSeverity
+	highSeverity
+	criticalSeverity
+)
+
+func parseSeverity(s string) (vulnSeverity, error) {
+	switch strings.ToUpper(s) {
+	case "LOW":
+		return lowSeverity, nil
+	case "MODERATE", "MEDIUM":
+		return moderateSeverity, nil
+	case "HIGH":
+		return highSeverity, nil
+	case "CRITICAL":
+		return criticalSeverity, nil
+	}
+	return unknownSeverity, fmt.Errorf("unknown severity %q", s)
+}
+
+// loadVulnDB loads every OSV entry in path, which is either a directory or
+// a zip file. Index files, like those in vuln.go.dev's index directory, are
+// skipped.
+func loadVulnDB(path string) (*vulnDB, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vulnerability database: %w", err)
+	}
+	var fsys fs.FS
+	if fi.IsDir() {
+		fsys = os.DirFS(path)
+	} else {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open vulnerability database: %w", err)
+		}
+		defer zr.Close()
+		fsys = zr
+	}
+	return parseVulnDB(fsys)
+}
+
+func parseVulnDB(fsys fs.FS) (*vulnDB, error) {
+	db := &vulnDB{modules: map[string][]osvAffected{}}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "index" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if path.Ext(name) != ".json" {
+			return nil
+		}
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		var e osvEntry
+		if err := json.Unmarshal(b, &e); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if e.ID == "" || e.Withdrawn != nil {
+			return nil
+		}
+		severity, _ := parseSeverity(e.DatabaseSpecific.Severity)
+		for _, a := range e.Affected {
+			if a.Package.Ecosystem != "Go" || a.Package.Name == "" {
+				continue
+			}
+			db.modules[a.Package.Name] = append(db.modules[a.Package.Name], osvAffected{
+				id:       e.ID,
+				severity: severity,
+				ranges:   a.Ranges,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vulnerability database: %w", err)
+	}
+	return db, nil
+}
+
+// Advisories returns the number of advisories in the database.
+func (db *vulnDB) Advisories() int {
+	ids := map[string]bool{}
+	for _, affected := range db.modules {
+		for _, a := range affected {
+			ids[a.id] = true
+		}
+	}
+	return len(ids)
+}
+
+// Fixes returns the IDs of advisories for which version of modulePath,
+// which may be escaped as in proxy URLs, is the first fixed version.
+func (db *vulnDB) Fixes(modulePath, version string) []string {
+	if db == nil {
+		return nil
+	}
+	if unescaped, err := module.UnescapePath(modulePath); err == nil {
+		modulePath = unescaped
+	}
+	if unescaped, err := module.UnescapeVersion(version); err == nil {
+		version = unescaped
+	}
+	version = semver.Canonical(version)
+	if version == "" {
+		return nil
+	}
+
+	var ids []string
+	for _, a := range db.modules[modulePath] {
+		for _, r := range a.ranges {
+			if r.Type != "SEMVER" {
+				continue
+			}
+			for _, ev := range r.Events {
+				if ev.Fixed != "" && osvVersion(ev.Fixed) == version {
+					ids = append(ids, a.id)
+				}
+			}
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// Affecting returns the IDs of advisories affecting version of modulePath,
+// which may be escaped as in proxy URLs. Advisories below minSeverity are
+// ignored, but advisories of unknown severity never are.
+func (db *vulnDB) Affecting(modulePath, version string, minSeverity vulnSeverity) []string {
+	if db == nil {
+		return nil
+	}
+	if unescaped, err := module.UnescapePath(modulePath); err == nil {
+		modulePath = unescaped
+	}
+	if unescaped, err := module.UnescapeVersion(version); err == nil {
+		version = unescaped
+	}
+	version = semver.Canonical(version)
+	if version == "" {
+		return nil
+	}
+
+	var ids []string
+	for _, a := range db.modules[modulePath] {
+		if a.severity != unknownSeverity && a.severity < minSeverity {
+			continue
+		}
+		for _, r := range a.ranges {
+			if r.Type == "SEMVER" && r.affects(version) {
+				ids = append(ids, a.id)
+				break
+			}
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// affects reports whether the canonical version is in the range. Events
+// are applied in version order, as the OSV schema specifies: versions at or
+// after an introduced event are affected, until a fixed event.
+func (r osvRange) affects(version string) bool {
+	type event struct {
+		version string // "" sorts before every version
+		fixed   bool
+	}
+	var events []event
+	for _, ev := range r.Events {
+		switch {
+		case ev.Introduced == "0":
+			events = append(events, event{})
+		case ev.Introduced != "":
+			events = append(events, event{version: osvVersion(ev.Introduced)})
+		case ev.Fixed != "":
+			events = append(events, event{version: osvVersion(ev.Fixed), fixed: true})
+		}
+	}
+	slices.SortStableFunc(events, func(a, b event) int {
+		return semver.Compare(a.version, b.version)
+	})
+
+	affected := false
+	for _, ev := range events {
+		if semver.Compare(ev.version, version) > 0 {
+			break
+		}
+		affected = !ev.fixed
+	}
+	return affected
+}
+
+// osvVersion converts an OSV SEMVER version, which has no "v" prefix, to a
+// canonical Go module version.
+func osvVersion(v string) string {
+	return semver.Canonical("v" + strings.TrimPrefix(v, "v"))
+}
@@ -0,0 +1,445 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+var testVulnFiles = map[string]string{
+	"ID/GO-2025-0001.json": `{
+  "schema_version": "1.3.1",
+  "id": "GO-2025-0001",
+  "modified": "2025-01-01T00:00:00Z",
+  "affected": [{
+    "package": {"name": "example.com/module", "ecosystem": "Go"},
+    "ranges": [{
+      "type": "SEMVER",
+      "events": [
+        {"introduced": "0"},
+        {"fixed": "1.2.0"},
+        {"introduced": "1.3.0"},
+        {"fixed": "1.3.1"}
+      ]
+    }]
+  }]
+}`,
+	"ID/GO-2025-0002.json": `{
+  "id": "GO-2025-0002",
+  "affected": [{
+    "package": {"name": "example.com/module", "ecosystem": "Go"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "1.0.0"}, {"fixed": "1.2.0"}]}]
+  }, {
+    "package": {"name": "github.com/Azure/sdk", "ecosystem": "Go"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "2.0.0+incompatible"}]}]
+  }]
+}`,
+	"ID/GO-2025-0003.json": `{
+  "id": "GO-2025-0003",
+  "withdrawn": "2025-02-01T00:00:00Z",
+  "affected": [{
+    "package": {"name": "example.com/module", "ecosystem": "Go"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.4.0"}]}]
+  }]
+}`,
+	"ID/PYSEC-2025-0001.json": `{
+  "id": "PYSEC-2025-0001",
+  "affected": [{
+    "package": {"name": "example.com/module", "ecosystem": "PyPI"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.5.0"}]}]
+  }]
+}`,
+	"GHSA-aaaa-bbbb-cccc.json": `{
+  "id": "GHSA-aaaa-bbbb-cccc",
+  "database_specific": {"severity": "LOW"},
+  "affected": [{
+    "package": {"name": "example.com/module", "ecosystem": "Go"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "1.2.0"}, {"fixed": "1.2.2"}]}]
+  }]
+}`,
+	"index/db.json":      `{"modified": "2025-01-01T00:00:00Z"}`,
+	"index/modules.json": `[{"path": "example.com/module"}]`,
+}
+
+func TestVulnDBFixes(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, content := range testVulnFiles {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	db, err := parseVulnDB(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := db.Advisories(); got != 3 {
+		t.Errorf("Advisories() = %d, want 3", got)
+	}
+
+	for _, tt := range []struct {
+		module, version string
+		want            []string
+	}{
+		{"example.com/module", "v1.2.0", []string{"GO-2025-0001", "GO-2025-0002"}},
+		{"example.com/module", "v1.3.1", []string{"GO-2025-0001"}},
+		{"example.com/module", "v1.3.0", nil},
+		{"example.com/module", "v1.4.0", nil}, // withdrawn
+		{"example.com/module", "v1.5.0", nil}, // other ecosystem
+		{"github.com/!azure/sdk", "v2.0.0+incompatible", []string{"GO-2025-0002"}},
+		{"example.com/other", "v1.2.0", nil},
+	} {
+		if got := db.Fixes(tt.module, tt.version); !slices.Equal(got, tt.want) {
+			t.Errorf("Fixes(%s@%s) = %v, want %v", tt.module, tt.version, got, tt.want)
+		}
+	}
+
+	var nilDB *vulnDB
+	if got := nilDB.Fixes("example.com/module", "v1.2.0"); got != nil {
+		t.Errorf("nil database: got %v", got)
+	}
+}
+
+func TestLoadVulnDB(t *testing.T) {
+	// As a directory
+	dir := t.TempDir()
+	for name, content := range testVulnFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db, err := loadVulnDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Fixes("example.com/module", "v1.3.1"); len(got) != 1 {
+		t.Errorf("directory: Fixes = %v", got)
+	}
+
+	// As a zip
+	zipPath := filepath.Join(t.TempDir(), "vulndb.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range testVulnFiles {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	db, err = loadVulnDB(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := db.Fixes("example.com/module", "v1.3.1"); len(got) != 1 {
+		t.Errorf("zip: Fixes = %v", got)
+	}
+
+	// Errors
+	if _, err := loadVulnDB(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected error loading missing database")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ID", "bad.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadVulnDB(dir); err == nil {
+		t.Errorf("expected error loading invalid entry")
+	}
+}
+
+func TestSecurityFixExemption(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	// v1.1.0 is old; v1.2.0, which fixes a vulnerability, and v1.2.1 are new
+	times := map[string]time.Time{
+		"v1.1.0": time.Now().Add(-30 * 24 * time.Hour),
+		"v1.2.0": time.Now().Add(-2 * time.Hour),
+		"v1.2.1": time.Now().Add(-1 * time.Hour),
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			w.Write([]byte("v1.1.0\nv1.2.0\nv1.2.1\n"))
+		case strings.HasSuffix(r.URL.Path, "/@latest"):
+			json.NewEncoder(w).Encode(VersionInfo{Version: "v1.2.1", Time: times["v1.2.1"]})
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: times[version]})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	fsys := fstest.MapFS{}
+	for name, content := range testVulnFiles {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	vulns, err := parseVulnDB(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := lru.New[string, *VersionInfo](100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := &Proxy{
+		upstream:        upstream.URL,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		cache:           cache,
+		defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+		listConcurrency: 4,
+		listTimeout:     30 * time.Second,
+		vulns:           vulns,
+	}
+
+	const wantHeader = "v1.2.0 GO-2025-0001,GO-2025-0002"
+	for _, tt := range []struct {
+		desc       string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantList   string
+		wantHeader string
+	}{{
+		desc:       "list",
+		path:       "/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.1.0\nv1.2.0\n",
+		wantHeader: wantHeader,
+	}, {
+		desc:       "info",
+		path:       "/example.com/module/@v/v1.2.0.info",
+		wantStatus: http.StatusOK,
+		wantHeader: wantHeader,
+	}, {
+		desc:       "info for version that isn't a fix",
+		path:       "/example.com/module/@v/v1.2.1.info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "info for old version",
+		path:       "/example.com/module/@v/v1.1.0.info",
+		wantStatus: http.StatusOK,
+	}, {
+		desc:       "latest",
+		path:       "/example.com/module/@latest",
+		wantStatus: http.StatusOK,
+		wantBody:   `"Version":"v1.2.0"`,
+		wantHeader: wantHeader,
+	}, {
+		desc:       "download",
+		path:       "/example.com/module/@v/v1.2.0.zip",
+		wantStatus: http.StatusTemporaryRedirect,
+		wantHeader: wantHeader,
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body: got %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantList != "" && w.Body.String() != tt.wantList {
+				t.Errorf("list: got %q, want %q", w.Body.String(), tt.wantList)
+			}
+			if got := w.Header().Get(securityFixHeader); got != tt.wantHeader {
+				t.Errorf("%s: got %q, want %q", securityFixHeader, got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestVulnDBAffecting(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, content := range testVulnFiles {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	db, err := parseVulnDB(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		module, version string
+		minSeverity     vulnSeverity
+		want            []string
+	}{
+		{"example.com/module", "v0.9.0", unknownSeverity, []string{"GO-2025-0001"}},
+		{"example.com/module", "v1.0.0", unknownSeverity, []string{"GO-2025-0001", "GO-2025-0002"}},
+		{"example.com/module", "v1.1.0-rc.1", unknownSeverity, []string{"GO-2025-0001", "GO-2025-0002"}},
+		{"example.com/module", "v1.1.0-!r!c1", unknownSeverity, []string{"GO-2025-0001", "GO-2025-0002"}}, // escaped
+		{"example.com/module", "v1.2.0", unknownSeverity, []string{"GHSA-aaaa-bbbb-cccc"}},
+		{"example.com/module", "v1.2.0", lowSeverity, []string{"GHSA-aaaa-bbbb-cccc"}},
+		{"example.com/module", "v1.2.0", highSeverity, nil},
+		{"example.com/module", "v1.2.5", unknownSeverity, nil},
+		{"example.com/module", "v1.3.0", unknownSeverity, []string{"GO-2025-0001"}},
+		{"example.com/module", "v1.3.0", criticalSeverity, []string{"GO-2025-0001"}}, // unknown severity
+		{"example.com/module", "v1.3.1", unknownSeverity, nil},
+		{"example.com/module", "v1.3.5", unknownSeverity, nil}, // withdrawn
+		{"github.com/!azure/sdk", "v1.5.0", unknownSeverity, []string{"GO-2025-0002"}},
+		{"github.com/!azure/sdk", "v2.0.0+incompatible", unknownSeverity, nil},
+		{"example.com/other", "v1.0.0", unknownSeverity, nil},
+	} {
+		if got := db.Affecting(tt.module, tt.version, tt.minSeverity); !slices.Equal(got, tt.want) {
+			t.Errorf("Affecting(%s@%s, %d) = %v, want %v", tt.module, tt.version, tt.minSeverity, got, tt.want)
+		}
+	}
+}
+
+func TestBlockVulnerable(t *testing.T) {
+	ctx := context.Background()
+	log := clog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	ctx = clog.WithLogger(ctx, log)
+
+	// Every version is past its cooldown
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		old := time.Now().Add(-30 * 24 * time.Hour)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/@v/list"):
+			w.Write([]byte("v1.0.0\nv1.2.0\nv1.2.5\nv1.3.0\n"))
+		case strings.HasSuffix(r.URL.Path, "/@latest"):
+			json.NewEncoder(w).Encode(VersionInfo{Version: "v1.3.0", Time: old})
+		case strings.HasSuffix(r.URL.Path, ".info"):
+			version := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".info")
+			json.NewEncoder(w).Encode(VersionInfo{Version: version, Time: old})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	fsys := fstest.MapFS{}
+	for name, content := range testVulnFiles {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	vulns, err := parseVulnDB(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newProxy := func(block bool, minSeverity vulnSeverity) *Proxy {
+		cache, err := lru.New[string, *VersionInfo](100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Proxy{
+			upstream:        upstream.URL,
+			client:          &http.Client{Timeout: 30 * time.Second},
+			cache:           cache,
+			defaultCooldown: fixedCooldown(7 * 24 * time.Hour),
+			listConcurrency: 4,
+			listTimeout:     30 * time.Second,
+			vulns:           vulns,
+			blockVulnerable: block,
+			blockSeverity:   minSeverity,
+		}
+	}
+
+	for _, tt := range []struct {
+		desc        string
+		block       bool
+		minSeverity vulnSeverity
+		path        string
+		wantStatus  int
+		wantBody    string
+		wantList    string
+	}{{
+		desc:       "not blocking",
+		path:       "/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.0.0\nv1.2.0\nv1.2.5\nv1.3.0\n",
+	}, {
+		desc:       "list",
+		block:      true,
+		path:       "/example.com/module/@v/list",
+		wantStatus: http.StatusOK,
+		wantList:   "v1.2.5\n",
+	}, {
+		desc:       "latest",
+		block:      true,
+		path:       "/example.com/module/@latest",
+		wantStatus: http.StatusOK,
+		wantBody:   `"Version":"v1.2.5"`,
+	}, {
+		desc:       "info",
+		block:      true,
+		path:       "/example.com/module/@v/v1.0.0.info",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "download",
+		block:      true,
+		path:       "/example.com/module/@v/v1.3.0.zip",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:       "escaped version",
+		block:      true,
+		path:       "/example.com/module/@v/v1.1.0-!r!c1.zip",
+		wantStatus: http.StatusNotFound,
+	}, {
+		desc:        "list with severity threshold",
+		block:       true,
+		minSeverity: highSeverity,
+		path:        "/example.com/module/@v/list",
+		wantStatus:  http.StatusOK,
+		wantList:    "v1.2.0\nv1.2.5\n",
+	}, {
+		desc:        "info with severity threshold",
+		block:       true,
+		minSeverity: highSeverity,
+		path:        "/example.com/module/@v/v1.2.0.info",
+		wantStatus:  http.StatusOK,
+	}} {
+		t.Run(tt.desc, func(t *testing.T) {
+			proxy := newProxy(tt.block, tt.minSeverity)
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			proxy.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body: got %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantList != "" && w.Body.String() != tt.wantList {
+				t.Errorf("list: got %q, want %q", w.Body.String(), tt.wantList)
+			}
+		})
+	}
+}